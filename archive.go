@@ -0,0 +1,498 @@
+package walk
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	. "strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// errArchiveReadOnly is returned when modifying files inside an archive.
+var errArchiveReadOnly = errors.New("archive is read-only, extract files with X")
+
+// archiveExts are the extensions of archives browsed as directories.
+var archiveExts = []string{".zip", ".tar", ".tar.gz", ".tgz"}
+
+// archive is an archive file browsed as a directory.
+type archive struct {
+	path   string    // Absolute path of archive file.
+	fsys   fs.FS     // Files in archive.
+	closer io.Closer // Closes archive file, if open.
+}
+
+// archiveExt returns the archive extension of the file named name, or "" if
+// it is not an archive.
+func archiveExt(name string) string {
+	for _, ext := range archiveExts {
+		if HasSuffix(ToLower(name), ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// openArchive opens the archive at path.
+func openArchive(path string) (*archive, error) {
+	switch archiveExt(path) {
+	case ".zip":
+		r, err := zip.OpenReader(path)
+		if err != nil {
+			return nil, err
+		}
+		return &archive{path: path, fsys: r, closer: r}, nil
+	case ".tar", ".tar.gz", ".tgz":
+		fsys, err := newTarFS(path)
+		if err != nil {
+			return nil, err
+		}
+		return &archive{path: path, fsys: fsys}, nil
+	}
+	return nil, fmt.Errorf("%v: not an archive", path)
+}
+
+// close closes the archive file, if open.
+func (a *archive) close() {
+	if a.closer != nil {
+		_ = a.closer.Close()
+	}
+}
+
+// name returns the path of the file at p in the archive, as used by its
+// fs.FS, if p is inside the archive.
+func (a *archive) name(p string) (string, bool) {
+	if p == a.path {
+		return ".", true
+	}
+	if name := TrimPrefix(p, a.path+"/"); name != p {
+		return name, true
+	}
+	return "", false
+}
+
+// enterArchive browses the archive at path as a directory.
+func (m *Model) enterArchive(path string) error {
+	a, err := openArchive(path)
+	if err != nil {
+		return err
+	}
+	m.archive = a
+	return nil
+}
+
+// leaveArchive stops browsing the archive unless the current path is inside
+// it.
+func (m *Model) leaveArchive() {
+	if m.archive == nil {
+		return
+	}
+	if _, ok := m.archive.name(m.path); !ok {
+		m.dropArchivePreviews(m.archive)
+		m.archive.close()
+		m.archive = nil
+	}
+}
+
+// dropArchivePreviews cancels and forgets the previews of files in archive
+// a, which read from it.
+func (m *Model) dropArchivePreviews(a *archive) {
+	if m.previewPending.archive == a {
+		m.cancelPreview()
+	}
+	if m.previewBuf != nil && m.previewBuf.key.archive == a {
+		m.previewBuf.toEnd = false
+		m.previewBuf = nil
+	}
+	m.previewCache.removeArchive(a)
+}
+
+// archiveLink returns the target of the symbolic link name in fsys, which
+// tar archives store in its header and zip archives as its content.
+func archiveLink(fsys fs.FS, name string) (string, error) {
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return "", err
+	}
+	if header, ok := info.Sys().(*tar.Header); ok {
+		return header.Linkname, nil
+	}
+	target, err := fs.ReadFile(fsys, name)
+	return string(target), err
+}
+
+// extract queues a job extracting the file under the cursor from the archive
+// being browsed to the directory containing the archive. Outside of archives,
+// it extracts the archive under the cursor to a new directory named after it.
+func (m *Model) extract() (tea.Cmd, error) {
+	filePath, ok := m.filePath()
+	if !ok {
+		return nil, nil
+	}
+	archivePath, name := filePath, "."
+	if m.archive != nil {
+		archivePath = m.archive.path
+		name, _ = m.archive.name(filePath)
+	} else if archiveExt(filePath) == "" {
+		return nil, fmt.Errorf("%v is not an archive", path.Base(filePath))
+	}
+	m.jobId++
+	j := newJob(m.jobId, jobExtract, []string{archivePath}, filepath.Dir(archivePath))
+	j.member = name
+	return m.queueJob(j), nil
+}
+
+// newPath returns path if no file exists there, else a unique path like it.
+func newPath(path string) string {
+	if _, err := os.Lstat(path); errors.Is(err, fs.ErrNotExist) {
+		return path
+	}
+	return uniquePath(path)
+}
+
+// extract extracts the file member of the archive at srcs[0] to a new file
+// in the destination directory, and returns its path.
+func (t *transfer) extract() (string, error) {
+	a, err := openArchive(t.srcs[0])
+	if err != nil {
+		return "", err
+	}
+	defer a.close()
+	base := path.Base(t.member)
+	if t.member == "." {
+		base = filepath.Base(t.srcs[0])
+		base = base[:len(base)-len(archiveExt(base))]
+	}
+	dst := newPath(filepath.Join(t.dst, base))
+	_ = fs.WalkDir(a.fsys, t.member, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				t.total += info.Size()
+			}
+		}
+		return nil
+	})
+	return dst, t.extractFS(a.fsys, t.member, dst)
+}
+
+// extractFS extracts the file or directory root in fsys to dst.
+func (t *transfer) extractFS(fsys fs.FS, root, dst string) error {
+	// target returns the path the file name in fsys is extracted to, if
+	// inside root.
+	target := func(name string) (string, bool) {
+		switch {
+		case name == root:
+			return dst, true
+		case root == ".":
+			return filepath.Join(dst, filepath.FromSlash(name)), true
+		case HasPrefix(name, root+"/"):
+			return filepath.Join(dst, filepath.FromSlash(name[len(root)+1:])), true
+		}
+		return "", false
+	}
+	// Hard links have no content of their own. They are made once the files
+	// they link to are extracted.
+	links := make(map[string]string)
+	err := fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := t.ctx.Err(); err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		dst, _ := target(name)
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(dst, 0o755)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := archiveLink(fsys, name)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, dst)
+		case !d.Type().IsRegular():
+			return nil // Skip devices, pipes, etc.
+		}
+		if header, ok := info.Sys().(*tar.Header); ok && header.Typeflag == tar.TypeLink {
+			links[dst] = path.Clean(TrimPrefix(header.Linkname, "./"))
+			return nil
+		}
+		return t.extractFile(fsys, name, dst, info.Mode().Perm()|0o200)
+	})
+	if err != nil {
+		return err
+	}
+	for dst, name := range links {
+		// Extract the content of files linked to outside of root instead.
+		if linked, ok := target(name); ok {
+			if _, err := os.Lstat(linked); err == nil {
+				if err := os.Link(linked, dst); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		info, err := fs.Stat(fsys, name)
+		if err != nil {
+			return err
+		}
+		if err := t.extractFile(fsys, name, dst, info.Mode().Perm()|0o200); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractFile extracts the regular file name in fsys to dst.
+func (t *transfer) extractFile(fsys fs.FS, name, dst string, perm fs.FileMode) error {
+	src, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	return t.writeFile(dst, src, perm, name)
+}
+
+// tarFS is a read-only fs.FS of the files in a tar archive, optionally gzip
+// compressed. Only the headers are kept in memory; files are read from the
+// archive when opened, directly at their offset if it is not compressed.
+type tarFS struct {
+	path    string               // Path of archive file.
+	entries map[string]*tarEntry // Entries by name, including implied directories.
+}
+
+// tarEntry is a file in a tar archive. It implements both fs.DirEntry and
+// fs.FileInfo.
+type tarEntry struct {
+	name     string      // Path in archive; "." for root.
+	header   *tar.Header // Header of file; nil for implied directories.
+	index    int         // Index of header in archive.
+	offset   int64       // Offset of content in archive; -1 if compressed.
+	children []*tarEntry // Files in directory, sorted by name.
+}
+
+// newTarFS reads the headers of the tar archive at name.
+func newTarFS(name string) (*tarFS, error) {
+	t := &tarFS{
+		path:    name,
+		entries: map[string]*tarEntry{".": {name: ".", offset: -1}},
+	}
+	r, err := t.reader()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	tr := tar.NewReader(r)
+	for i := 0; ; i++ {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		offset := contentOffset(r, header)
+		name := path.Clean(TrimPrefix(header.Name, "./"))
+		if name == "." || !fs.ValidPath(name) {
+			continue
+		}
+		parent := t.dir(path.Dir(name))
+		if parent == nil {
+			continue // Inside a file, e.g., a symbolic link.
+		}
+		if e, ok := t.entries[name]; ok {
+			e.header, e.index, e.offset = header, i, offset // Replaced or implied before.
+			continue
+		}
+		e := &tarEntry{name: name, header: header, index: i, offset: offset}
+		t.entries[name] = e
+		parent.children = append(parent.children, e)
+	}
+	for _, e := range t.entries {
+		sort.Slice(e.children, func(i, j int) bool {
+			return e.children[i].name < e.children[j].name
+		})
+	}
+	return t, nil
+}
+
+// dir returns the directory entry name, implying it and its parents if not
+// in the archive, or nil if a parent is not a directory.
+func (t *tarFS) dir(name string) *tarEntry {
+	if e, ok := t.entries[name]; ok {
+		if !e.IsDir() {
+			return nil
+		}
+		return e
+	}
+	parent := t.dir(path.Dir(name))
+	if parent == nil {
+		return nil
+	}
+	e := &tarEntry{name: name, index: -1, offset: -1}
+	t.entries[name] = e
+	parent.children = append(parent.children, e)
+	return e
+}
+
+// contentOffset returns the offset in the archive read by r of the content
+// of the file whose header was just read, or -1 if it cannot be read directly,
+// i.e., if the archive is compressed or the file is not stored contiguously.
+func contentOffset(r io.Reader, header *tar.Header) int64 {
+	s, ok := r.(io.Seeker)
+	if !ok || header.Typeflag != tar.TypeReg {
+		return -1
+	}
+	for key := range header.PAXRecords {
+		if HasPrefix(key, "GNU.sparse.") {
+			return -1
+		}
+	}
+	offset, err := s.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return -1
+	}
+	return offset
+}
+
+// reader opens the archive, decompressing it if needed.
+func (t *tarFS) reader() (io.ReadCloser, error) {
+	file, err := os.Open(t.path)
+	if err != nil {
+		return nil, err
+	}
+	if archiveExt(t.path) == ".tar" {
+		return file, nil
+	}
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{gz, file}, nil
+}
+
+// Open opens the file name, reading the archive up to its content unless its
+// offset is known.
+func (t *tarFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	e, ok := t.entries[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if e.IsDir() {
+		return &tarDir{entry: e}, nil
+	}
+	if e.offset >= 0 {
+		file, err := os.Open(t.path)
+		if err != nil {
+			return nil, err
+		}
+		content := io.NewSectionReader(file, e.offset, e.Size())
+		return &tarSection{entry: e, SectionReader: content, closer: file}, nil
+	}
+	r, err := t.reader()
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(r)
+	for i := 0; i <= e.index; i++ {
+		if _, err := tr.Next(); err != nil {
+			r.Close()
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+	}
+	return &tarFile{entry: e, Reader: tr, closer: r}, nil
+}
+
+func (e *tarEntry) Name() string { return path.Base(e.name) }
+func (e *tarEntry) Size() int64 {
+	if e.header == nil {
+		return 0
+	}
+	return e.header.Size
+}
+func (e *tarEntry) Mode() fs.FileMode {
+	if e.header == nil {
+		return fs.ModeDir | 0o755
+	}
+	return e.header.FileInfo().Mode()
+}
+func (e *tarEntry) ModTime() time.Time {
+	if e.header == nil {
+		return time.Time{}
+	}
+	return e.header.ModTime
+}
+func (e *tarEntry) IsDir() bool                { return e.Mode().IsDir() }
+func (e *tarEntry) Sys() any                   { return e.header }
+func (e *tarEntry) Type() fs.FileMode          { return e.Mode().Type() }
+func (e *tarEntry) Info() (fs.FileInfo, error) { return e, nil }
+
+// tarFile is a file opened in a tar archive.
+type tarFile struct {
+	entry *tarEntry
+	io.Reader
+	closer io.Closer
+}
+
+func (f *tarFile) Stat() (fs.FileInfo, error) { return f.entry, nil }
+func (f *tarFile) Close() error               { return f.closer.Close() }
+
+// tarSection is a file opened at its offset in an uncompressed tar archive,
+// which can also be read at any offset.
+type tarSection struct {
+	entry *tarEntry
+	*io.SectionReader
+	closer io.Closer
+}
+
+func (f *tarSection) Stat() (fs.FileInfo, error) { return f.entry, nil }
+func (f *tarSection) Close() error               { return f.closer.Close() }
+
+// tarDir is a directory opened in a tar archive.
+type tarDir struct {
+	entry  *tarEntry
+	offset int // Number of entries read.
+}
+
+func (d *tarDir) Stat() (fs.FileInfo, error) { return d.entry, nil }
+func (d *tarDir) Close() error               { return nil }
+func (d *tarDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.entry.name, Err: errors.New("is a directory")}
+}
+
+// ReadDir reads the next n entries of the directory, or all if n <= 0.
+func (d *tarDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entry.children[d.offset:]
+	if n > 0 && len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > 0 && len(rest) > n {
+		rest = rest[:n]
+	}
+	d.offset += len(rest)
+	entries := make([]fs.DirEntry, len(rest))
+	for i, e := range rest {
+		entries[i] = e
+	}
+	return entries, nil
+}
@@ -0,0 +1,205 @@
+package walk
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	. "strings"
+	"testing"
+)
+
+// writeTar writes a tar archive at path with a file, a hard link to it and a
+// directory containing another hard link to it.
+func writeTar(t *testing.T, path string) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	w := tar.NewWriter(file)
+	headers := []*tar.Header{
+		{Name: "file", Typeflag: tar.TypeReg, Mode: 0o644, Size: 4},
+		{Name: "link", Typeflag: tar.TypeLink, Linkname: "file", Mode: 0o644},
+		{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0o755},
+		{Name: "dir/link", Typeflag: tar.TypeLink, Linkname: "file", Mode: 0o644},
+	}
+	for _, header := range headers {
+		if err := w.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if header.Size > 0 {
+			if _, err := w.Write([]byte("data")); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractHardLinks(t *testing.T) {
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "archive.tar")
+	writeTar(t, archivePath)
+
+	j := newJob(1, jobExtract, []string{archivePath}, dir)
+	j.member = "."
+	msg := runJob(t, j, conflictSkip)
+	if msg.err != nil {
+		t.Fatal(msg.err)
+	}
+	if want := filepath.Join(dir, "archive"); msg.last != want {
+		t.Errorf("extracted to %q, want %q", msg.last, want)
+	}
+	file, err := os.Stat(filepath.Join(msg.last, "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"link", "dir/link"} {
+		link, err := os.Stat(filepath.Join(msg.last, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !os.SameFile(file, link) {
+			t.Errorf("%v is not a hard link to file", name)
+		}
+	}
+
+	// Files linked to outside of the extracted directory are copied.
+	j = newJob(2, jobExtract, []string{archivePath}, dir)
+	j.member = "dir"
+	msg = runJob(t, j, conflictSkip)
+	if msg.err != nil {
+		t.Fatal(msg.err)
+	}
+	content, err := os.ReadFile(filepath.Join(msg.last, "link"))
+	if err != nil || string(content) != "data" {
+		t.Errorf("content = %q, %v, want %q", content, err, "data")
+	}
+}
+
+func TestExtractCancel(t *testing.T) {
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "archive.tar")
+	writeTar(t, archivePath)
+
+	j := newJob(1, jobExtract, []string{archivePath}, dir)
+	j.member = "."
+	j.cancel()
+	if msg := runJob(t, j, conflictSkip); msg.err == nil {
+		t.Error("cancelled extraction succeeded")
+	}
+}
+
+func TestArchivePreviewReentered(t *testing.T) {
+	dir := t.TempDir()
+	file, err := os.Create(filepath.Join(dir, "archive.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(file)
+	member, err := w.Create("log.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const lines = 200000
+	for i := 1; i <= lines; i++ {
+		fmt.Fprintf(member, "line %d\n", i)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	m := New(Path(dir), Size(80, 24))
+	m.Init()
+	send(m, press("`"))
+	for i := 0; i < 2; i++ {
+		moveTo(t, m, "archive.zip")
+		send(m, press("o"))
+		moveTo(t, m, "log.txt")
+		send(m, press("b"))
+	}
+	if m.archive != nil {
+		t.Fatal("archive not left")
+	}
+	moveTo(t, m, "archive.zip")
+	send(m, press("o"))
+	moveTo(t, m, "log.txt")
+	send(m, press("f"))
+	send(m, press("end"))
+	view := Split(m.previewView(), "\n")
+	if last := view[len(view)-1]; !HasSuffix(last, fmt.Sprintf("line %d", lines)) {
+		t.Errorf("last line shown = %q", last)
+	}
+}
+
+func TestTarMemberChunks(t *testing.T) {
+	dir := t.TempDir()
+	var content Builder
+	for i := 1; content.Len() < 3*previewChunkSize; i++ {
+		fmt.Fprintf(&content, "line %d\n", i)
+	}
+	long := Repeat("d", 120) + "/log.txt" // Stored with a PAX header.
+	for _, name := range []string{"archive.tar", "archive.tar.gz"} {
+		path := filepath.Join(dir, name)
+		file, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out io.WriteCloser = file
+		if name != "archive.tar" {
+			out = gzip.NewWriter(file)
+		}
+		w := tar.NewWriter(out)
+		for _, member := range []string{"first.txt", long} {
+			header := &tar.Header{Name: member, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(content.Len())}
+			if err := w.WriteHeader(header); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write([]byte(content.String())); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		out.Close()
+		file.Close()
+
+		fsys, err := newTarFS(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		member, err := fsys.Open(long)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, direct := member.(io.ReaderAt)
+		member.Close()
+		if want := name == "archive.tar"; direct != want {
+			t.Errorf("%v: member read at offset = %v, want %v", name, direct, want)
+		}
+
+		r := &chunkReader{src: &previewSource{fsys: fsys, name: long}}
+		var got []byte
+		for eof := false; !eof; {
+			var chunk []byte
+			chunk, eof, err = r.read()
+			if err != nil {
+				t.Fatalf("%v: %v", name, err)
+			}
+			got = append(got, chunk...)
+		}
+		r.close()
+		if string(got) != content.String() {
+			t.Errorf("%v: read %d bytes, want %d", name, len(got), content.Len())
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build windows || plan9
+
+package walk
+
+import "io"
+
+// cellSize returns the default size in pixels of a terminal cell, as it
+// cannot be queried.
+func cellSize(io.Writer) (width, height int) {
+	return defaultCellWidth, defaultCellHeight
+}
@@ -0,0 +1,26 @@
+//go:build !windows && !plan9
+
+package walk
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// cellSize returns the size in pixels of a cell of the terminal written to by
+// out, as reported by the terminal, or a default size.
+func cellSize(out io.Writer) (width, height int) {
+	file, ok := out.(*os.File)
+	if out == nil {
+		file, ok = os.Stdout, true
+	}
+	if ok {
+		size, err := unix.IoctlGetWinsize(int(file.Fd()), unix.TIOCGWINSZ)
+		if err == nil && size.Col > 0 && size.Row > 0 && size.Xpixel > 0 && size.Ypixel > 0 {
+			return int(size.Xpixel / size.Col), int(size.Ypixel / size.Row)
+		}
+	}
+	return defaultCellWidth, defaultCellHeight
+}
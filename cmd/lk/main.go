@@ -19,7 +19,7 @@ func usage(s *walk.Styles) {
 		exe = os.Args[0]
 	}
 	exe = filepath.Base(exe)
-        _, _ = fmt.Fprintf(os.Stderr, "\n  "+s.Cursor.Render(" " + exe + " ")+"\n\n  Usage: " + exe + " [flags] [path]\n\n")
+        _, _ = fmt.Fprintf(os.Stderr, "\n  "+s.Cursor.Render(" " + exe + " ")+"\n\n  Usage: " + exe + " [flags] [path]\n         <paths> | " + exe + " [flags]\n\n")
         w := tabwriter.NewWriter(os.Stderr, 0, 8, 2, ' ', 0)
         put := func(s string) {
                 _, _ = fmt.Fprintln(w, s)
@@ -30,13 +30,43 @@ func usage(s *walk.Styles) {
         put("    Space\tToggle preview")
         put("    Esc, q\tExit with cd")
         put("    Ctrl+c\tExit without cd")
-        put("    /\tFuzzy search")
+        put("    /\tFuzzy filter")
+        put("    Ctrl+n, Ctrl+p\tNext or previous match")
+        put("    Ctrl+u\tClear filter")
         put("    dd\tDelete file or dir")
         put("    y\tYank current directory path to clipboard")
+        put("    m\tMark or unmark file")
+        put("    v\tStart or end range selection")
+        put("    Ctrl+a, *, M\tMark all, invert marks, clear marks")
+        put("    c, x\tYank marked files to copy or cut")
+        put("    P\tPaste yanked files into current directory")
+        put("    Ctrl+x\tCancel running copy or move")
+        put("    T\tBrowse trash, (r)estore file")
+        put("    r\tRename file")
+        put("    R\tRename marked files with editor")
+        put("    a, A\tCreate file or directory")
+        put("    L, H\tCreate symlink or hard link to file")
+        put("    i\tToggle long listing")
+        put("    t\tToggle rendered or raw Markdown preview")
+        put("    f\tFocus preview to scroll and search it")
+        put("    X\tExtract file from archive, or whole archive")
+        put("    J\tJump to target of symlink")
+        put("    s\tCycle sort mode (name, size, time, extension, type)")
+        put("    S\tReverse sort order")
+        put("    .\tToggle hidden files")
         put("\n  Flags:\n")
         put("    --help\t-h\tdisplay help")
         put("    --version\t-v\tdisplay version")
         put("    --icons\t-i\tdisplay icons")
+        put("    --all\t-a\tdisplay hidden files")
+        put("    --trash\t-t\tmove deleted files to trash")
+        put("    --long\t-l\tlong listing with [=columns]")
+        put("         (mode,user,group,size,time,target)")
+        put("    --follow\t-L\tenter real directories of symlinks")
+        put("    --colors\t\tfile colors from dircolors file [=path]")
+        put("    --numbers\t-n\tshow line numbers in preview")
+        put("    --style\t\tpreview syntax style [=name]")
+        put("    --images\t\timage protocol [=auto|blocks|kitty|sixel]")
 	put("    --command\t-c\t\"open\" file command line")
 	put("         (path replaces first {}, else appended)")
         _ = w.Flush()
@@ -54,12 +84,14 @@ func main() {
 	options := []walk.Option{
 		walk.Style(style),
 		walk.Size(80, 60),
+		walk.Output(os.Stderr),
 	}
 
 	startPath, err := os.Getwd()
 	if err != nil {
 		panic(err)
 	}
+	pathArg := false
 
 	for i := 1; i < len(os.Args); i++ {
 		if os.Args[i] == "--help" || os.Args[i] == "-h" {
@@ -75,6 +107,74 @@ func main() {
 			continue
 		}
 
+		if os.Args[i] == "--all" || os.Args[i] == "-a" {
+			options = append(options, walk.Hidden())
+			continue
+		}
+
+		if os.Args[i] == "--trash" || os.Args[i] == "-t" {
+			options = append(options, walk.Trash())
+			continue
+		}
+
+		if os.Args[i] == "--follow" || os.Args[i] == "-L" {
+			options = append(options, walk.FollowLinks())
+			continue
+		}
+
+		if os.Args[i] == "--numbers" || os.Args[i] == "-n" {
+			options = append(options, walk.LineNumbers())
+			continue
+		}
+
+		const imagesflag = "--images"
+		if strings.HasPrefix(os.Args[i], imagesflag+"=") {
+			protocol, err := walk.ParseImageProtocol(
+				strings.TrimPrefix(os.Args[i], imagesflag+"="),
+			)
+			if err != nil {
+				_, _ = fmt.Fprintln(os.Stderr, "error:", err)
+				os.Exit(1)
+			}
+			options = append(options, walk.Images(protocol))
+			continue
+		}
+
+		const colorsflag = "--colors"
+		if strings.HasPrefix(os.Args[i], colorsflag+"=") {
+			spec, err := os.ReadFile(strings.TrimPrefix(os.Args[i], colorsflag+"="))
+			if err != nil {
+				_, _ = fmt.Fprintln(os.Stderr, "error:", err)
+				os.Exit(1)
+			}
+			options = append(options, walk.Colors(string(spec)))
+			continue
+		}
+
+		const styleflag = "--style"
+		if strings.HasPrefix(os.Args[i], styleflag+"=") {
+			options = append(options, walk.SyntaxStyle(
+				strings.TrimPrefix(os.Args[i], styleflag+"="),
+			))
+			continue
+		}
+
+		const longflag = "--long"
+		if os.Args[i] == longflag || os.Args[i] == "-l" ||
+			strings.HasPrefix(os.Args[i], longflag+"=") {
+			var value string
+			if strings.HasPrefix(os.Args[i], longflag+"=") {
+				value = strings.TrimPrefix(os.Args[i], longflag+"=")
+			}
+			columns, err := walk.ParseColumns(value)
+			if err != nil {
+				_, _ = fmt.Fprintln(os.Stderr, "error:", err)
+				os.Exit(1)
+			}
+			options = append(options, walk.Long(columns...))
+			continue
+		}
+
 		const cmdflag = "--command"
 		if strings.HasPrefix(os.Args[i], cmdflag + "=") {
 			options = append(options, walk.Command(
@@ -94,13 +194,32 @@ func main() {
 			panic(err)
 		}
 		options = append(options, walk.Path(startPath))
+		pathArg = true
+	}
+
+	// Browse the paths piped to stdin, reading keys from the terminal instead.
+	var programOptions []tea.ProgramOption
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice == 0 {
+		if pathArg {
+			_, _ = fmt.Fprintln(os.Stderr, "error: cannot browse both a path and paths from stdin")
+			os.Exit(1)
+		}
+		fsys, err := walk.ReadPathList(os.Stdin)
+		if err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(1)
+		}
+		options = append(options, walk.FS(fsys))
+		programOptions = append(programOptions, tea.WithInputTTY())
 	}
 
 	output := termenv.NewOutput(os.Stderr)
 	lipgloss.SetColorProfile(output.ColorProfile())
 
 	w := walk.New(options...)
-	p := tea.NewProgram(w, tea.WithOutput(os.Stderr))
+	// Render where images are drawn, so that they do not interleave.
+	programOptions = append(programOptions, tea.WithOutput(w.Output()))
+	p := tea.NewProgram(w, programOptions...)
 
 	if _, err := p.Run(); err != nil {
 		panic(err)
@@ -0,0 +1,243 @@
+package walk
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	. "strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// defaultLSColors are the colors of files used if LS_COLORS is not set,
+// similar to those of GNU dircolors.
+const defaultLSColors = "di=01;34:ln=01;36:pi=40;33:so=01;35:bd=40;33;01:" +
+	"cd=40;33;01:or=40;31;01:su=37;41:sg=30;43:tw=30;42:ow=34;42:st=37;44:" +
+	"ex=01;32:" +
+	"*.tar=01;31:*.tgz=01;31:*.zip=01;31:*.gz=01;31:*.bz2=01;31:" +
+	"*.xz=01;31:*.zst=01;31:*.7z=01;31:*.rar=01;31:*.deb=01;31:*.rpm=01;31:" +
+	"*.jpg=01;35:*.jpeg=01;35:*.png=01;35:*.gif=01;35:*.bmp=01;35:" +
+	"*.tif=01;35:*.tiff=01;35:*.webp=01;35:*.svg=01;35:*.mp4=01;35:" +
+	"*.mkv=01;35:*.webm=01;35:*.avi=01;35:*.mov=01;35:" +
+	"*.mp3=00;36:*.flac=00;36:*.ogg=00;36:*.wav=00;36:*.m4a=00;36"
+
+// dircolorsKeys maps the keywords of the dircolors format to LS_COLORS keys.
+var dircolorsKeys = map[string]string{
+	"NORMAL":                "no",
+	"NORM":                  "no",
+	"FILE":                  "fi",
+	"RESET":                 "rs",
+	"DIR":                   "di",
+	"LNK":                   "ln",
+	"LINK":                  "ln",
+	"SYMLINK":               "ln",
+	"ORPHAN":                "or",
+	"MISSING":               "mi",
+	"FIFO":                  "pi",
+	"PIPE":                  "pi",
+	"SOCK":                  "so",
+	"BLK":                   "bd",
+	"BLOCK":                 "bd",
+	"CHR":                   "cd",
+	"CHAR":                  "cd",
+	"DOOR":                  "do",
+	"EXEC":                  "ex",
+	"SETUID":                "su",
+	"SETGID":                "sg",
+	"STICKY":                "st",
+	"OTHER_WRITABLE":        "ow",
+	"OWR":                   "ow",
+	"STICKY_OTHER_WRITABLE": "tw",
+	"OWT":                   "tw",
+	"CAPABILITY":            "ca",
+	"MULTIHARDLINK":         "mh",
+}
+
+// fileStyles are the styles of files by name.
+type fileStyles map[string]lipgloss.Style
+
+// colorMap holds the styles of files by the keys of LS_COLORS, i.e., file
+// types such as "di" and patterns such as "*.go".
+type colorMap struct {
+	styles     map[string]lipgloss.Style
+	linkTarget bool // Whether symlinks are colored as their target ("ln=target").
+}
+
+// defaultColors returns the colors of files set by LS_COLORS, or the default
+// colors if not set. It returns nil if NO_COLOR is set.
+func defaultColors() *colorMap {
+	if os.Getenv("NO_COLOR") != "" {
+		return nil
+	}
+	if spec, ok := os.LookupEnv("LS_COLORS"); ok {
+		return parseColors(spec)
+	}
+	return parseColors(defaultLSColors)
+}
+
+// parseColors returns the colors of files described by spec, in the format
+// of either LS_COLORS or a dircolors database. Invalid entries are skipped.
+func parseColors(spec string) *colorMap {
+	c := &colorMap{styles: make(map[string]lipgloss.Style)}
+	if !Contains(spec, "=") || Contains(spec, "\n") {
+		c.parseDircolors(spec)
+		return c
+	}
+	for _, entry := range Split(spec, ":") {
+		key, value, ok := Cut(entry, "=")
+		if ok {
+			c.set(key, value)
+		}
+	}
+	return c
+}
+
+// parseDircolors adds the colors of the dircolors database in content, as
+// output by "dircolors --print-database". Terminal conditions are ignored.
+func (c *colorMap) parseDircolors(content string) {
+	scanner := bufio.NewScanner(NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := Index(line, "#"); i == 0 || i > 0 && TrimRight(line[:i], " \t") != line[:i] {
+			line = line[:i]
+		}
+		fields := Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		keyword, value := fields[0], fields[1]
+		switch {
+		case HasPrefix(keyword, "."):
+			c.set("*"+keyword, value)
+		case HasPrefix(keyword, "*"):
+			c.set(keyword, value)
+		default:
+			if key, ok := dircolorsKeys[ToUpper(keyword)]; ok {
+				c.set(key, value)
+			}
+		}
+	}
+}
+
+// set sets the style of files matching key to the SGR parameters in value.
+func (c *colorMap) set(key, value string) {
+	if key == "ln" && value == "target" {
+		c.linkTarget = true
+		return
+	}
+	if HasPrefix(key, "*.") {
+		key = ToLower(key) // Extensions are matched case-insensitively.
+	}
+	if style, err := sgrStyle(value); err == nil {
+		c.styles[key] = style
+	}
+}
+
+// sgrStyle returns the style of the SGR parameters in codes, e.g., "01;34"
+// for bold blue.
+func sgrStyle(codes string) (lipgloss.Style, error) {
+	style := lipgloss.NewStyle()
+	params := Split(codes, ";")
+	for i := 0; i < len(params); i++ {
+		if params[i] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(params[i])
+		if err != nil {
+			return style, fmt.Errorf("invalid SGR parameter: %q", params[i])
+		}
+		switch {
+		case n == 0:
+			style = lipgloss.NewStyle()
+		case n == 1:
+			style = style.Bold(true)
+		case n == 2:
+			style = style.Faint(true)
+		case n == 3:
+			style = style.Italic(true)
+		case n == 4:
+			style = style.Underline(true)
+		case n == 5 || n == 6:
+			style = style.Blink(true)
+		case n == 7:
+			style = style.Reverse(true)
+		case n == 9:
+			style = style.Strikethrough(true)
+		case n >= 30 && n <= 37:
+			style = style.Foreground(lipgloss.Color(strconv.Itoa(n - 30)))
+		case n >= 40 && n <= 47:
+			style = style.Background(lipgloss.Color(strconv.Itoa(n - 40)))
+		case n >= 90 && n <= 97:
+			style = style.Foreground(lipgloss.Color(strconv.Itoa(n - 90 + 8)))
+		case n >= 100 && n <= 107:
+			style = style.Background(lipgloss.Color(strconv.Itoa(n - 100 + 8)))
+		case n == 38 || n == 48:
+			// Extended color: 5;n for 256 colors, 2;r;g;b for true color.
+			var color lipgloss.Color
+			switch {
+			case i+2 < len(params) && params[i+1] == "5":
+				color = lipgloss.Color(params[i+2])
+				i += 2
+			case i+4 < len(params) && params[i+1] == "2":
+				var rgb [3]int
+				for k := range rgb {
+					rgb[k], _ = strconv.Atoi(params[i+2+k])
+				}
+				color = lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", rgb[0], rgb[1], rgb[2]))
+				i += 4
+			default:
+				return style, fmt.Errorf("invalid SGR color: %q", codes)
+			}
+			if n == 38 {
+				style = style.Foreground(color)
+			} else {
+				style = style.Background(color)
+			}
+		}
+	}
+	return style, nil
+}
+
+// fileColor returns the style of file in the current directory, as computed
+// when listed, or no style if coloring is disabled.
+func (m *Model) fileColor(file fs.DirEntry) lipgloss.Style {
+	if style, ok := m.fileColors[file.Name()]; ok {
+		return style
+	}
+	return lipgloss.NewStyle()
+}
+
+// colorFiles computes the styles of the files in the current directory once
+// listed, so that rendering does not stat them again.
+func (m *Model) colorFiles() {
+	m.fileColors = nil
+	if m.colors == nil {
+		return
+	}
+	m.fileColors = make(fileStyles, len(m.listing))
+	for _, file := range m.listing {
+		m.fileColors[file.Name()] = m.computeColor(file)
+	}
+}
+
+// computeColor returns the style of file in the current directory.
+func (m *Model) computeColor(file fs.DirEntry) lipgloss.Style {
+	info, err := file.Info()
+	if err != nil {
+		return lipgloss.NewStyle()
+	}
+	orphan := m.isBrokenLink(m.path, file)
+	if m.colors.linkTarget && isLink(file) && !orphan {
+		if target, err := m.stat(m.join(m.path, file.Name())); err == nil {
+			info = target
+		}
+	}
+	for _, key := range fileKeys(info, orphan) {
+		if style, ok := m.colors.styles[key]; ok {
+			return style
+		}
+	}
+	return lipgloss.NewStyle()
+}
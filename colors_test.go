@@ -0,0 +1,90 @@
+package walk
+
+import (
+	"io/fs"
+	"reflect"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// countingEntry is a directory entry counting the calls of Info.
+type countingEntry struct {
+	fs.DirEntry
+	calls *int
+}
+
+func (e countingEntry) Info() (fs.FileInfo, error) {
+	*e.calls++
+	return e.DirEntry.Info()
+}
+
+func TestFileColorsCached(t *testing.T) {
+	m := New(FS(testFS()), Size(80, 24), Colors("di=01;34:*.txt=31"))
+	m.Init()
+	want := map[string]string{
+		"dir":     m.colors.styles["di"].Render("x"),
+		"top.txt": m.colors.styles["*.txt"].Render("x"),
+	}
+	var calls int
+	for i, file := range m.files {
+		m.files[i] = countingEntry{file, &calls}
+	}
+	m.View()
+	for _, file := range m.files {
+		if style, ok := want[file.Name()]; ok && m.fileColor(file).Render("x") != style {
+			t.Errorf("%v: color = %q, want %q", file.Name(), m.fileColor(file).Render("x"), style)
+		}
+	}
+	if calls > 0 {
+		t.Errorf("files stat %d times when rendered", calls)
+	}
+
+	m.WithColors("")
+	if m.fileColors != nil {
+		t.Error("colors kept when disabled")
+	}
+}
+
+func TestParseColors(t *testing.T) {
+	style := lipgloss.NewStyle()
+	tests := []struct {
+		name       string
+		spec       string
+		styles     map[string]lipgloss.Style
+		linkTarget bool
+	}{
+		{"LS_COLORS", "di=01;34:*.GO=31:*README=33:bad:ex=x1:",
+			map[string]lipgloss.Style{
+				"di":      style.Bold(true).Foreground(lipgloss.Color("4")),
+				"*.go":    style.Foreground(lipgloss.Color("1")),
+				"*README": style.Foreground(lipgloss.Color("3")),
+			}, false},
+		{"extended colors", "*.md=38;5;208:*.rs=38;2;255;0;16:di=48;5:so=91;104",
+			map[string]lipgloss.Style{
+				"*.md": style.Foreground(lipgloss.Color("208")),
+				"*.rs": style.Foreground(lipgloss.Color("#ff0010")),
+				"so":   style.Foreground(lipgloss.Color("9")).Background(lipgloss.Color("12")),
+			}, false},
+		{"reset", "fi=01;0;32:ln=target",
+			map[string]lipgloss.Style{"fi": style.Foreground(lipgloss.Color("2"))}, true},
+		{"dircolors", "# comment\nTERM xterm\nDIR 01;34 # directories\n.TXT 32\n" +
+			"*Makefile 33\nlink target\nUNKNOWN 31\nEXEC\n",
+			map[string]lipgloss.Style{
+				"di":        style.Bold(true).Foreground(lipgloss.Color("4")),
+				"*.txt":     style.Foreground(lipgloss.Color("2")),
+				"*Makefile": style.Foreground(lipgloss.Color("3")),
+			}, true},
+		{"dircolors line", "DIR 01;34",
+			map[string]lipgloss.Style{"di": style.Bold(true).Foreground(lipgloss.Color("4"))}, false},
+	}
+	for _, tt := range tests {
+		c := parseColors(tt.spec)
+		if !reflect.DeepEqual(c.styles, tt.styles) {
+			t.Errorf("%s: styles = %v, want %v", tt.name, c.styles, tt.styles)
+		}
+		if c.linkTarget != tt.linkTarget {
+			t.Errorf("%s: linkTarget = %v", tt.name, c.linkTarget)
+		}
+	}
+}
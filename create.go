@@ -0,0 +1,69 @@
+package walk
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	. "strings"
+)
+
+// create creates the file, directory or link at path name, relative to the
+// current directory, and selects it. Links point to the file under the cursor.
+func (m *Model) create(kind inputKind, name string) error {
+	if TrimSpace(name) == "" {
+		return fmt.Errorf("invalid file name: %q", name)
+	}
+	newPath := name
+	if !filepath.IsAbs(newPath) {
+		newPath = filepath.Join(m.path, newPath)
+	}
+	if _, err := os.Lstat(newPath); err == nil && kind != inputNewDir {
+		return fmt.Errorf("cannot create %v: file exists", name)
+	}
+	target, ok := m.filePath()
+	if !ok && (kind == inputSymlink || kind == inputHardLink) {
+		return nil
+	}
+	if kind != inputNewDir {
+		if err := os.MkdirAll(filepath.Dir(newPath), 0o755); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	switch kind {
+	case inputNewFile:
+		var file *os.File
+		file, err = os.OpenFile(newPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			err = file.Close()
+		}
+	case inputNewDir:
+		err = os.MkdirAll(newPath, 0o755)
+	case inputSymlink:
+		// Prefer relative targets, so that links survive moving both together.
+		if rel, rerr := filepath.Rel(filepath.Dir(newPath), target); rerr == nil {
+			target = rel
+		}
+		err = os.Symlink(target, newPath)
+	case inputHardLink:
+		err = os.Link(target, newPath)
+	}
+	if err != nil {
+		return err
+	}
+	m.list()
+	m.selectPath(newPath)
+	return nil
+}
+
+// selectPath moves the cursor to the file at path, or to the file in the
+// current directory that contains it, on next View.
+func (m *Model) selectPath(path string) {
+	rel, err := filepath.Rel(m.path, path)
+	if err != nil || rel == "." || HasPrefix(rel, "..") {
+		return
+	}
+	m.prevName = Split(rel, fileSeparator)[0]
+	m.findPrevName = true
+}
@@ -0,0 +1,198 @@
+package walk
+
+import (
+	"io/fs"
+	. "strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sahilm/fuzzy"
+)
+
+// defaultSearchTimeout is the delay after which type-to-filter input ends.
+const defaultSearchTimeout = 2 * time.Second
+
+// applyFilter narrows the files listed to those matching the current search,
+// ranked by fuzzy match score. All files are listed if the search is empty.
+func (m *Model) applyFilter() {
+	m.commitVisual()
+	m.matchedIndexes = nil
+	if m.search == "" {
+		m.files = m.listing
+		return
+	}
+	names := make([]string, len(m.listing))
+	for i, fi := range m.listing {
+		names[i] = fi.Name()
+	}
+	matches := fuzzy.Find(m.search, names)
+	m.files = make([]fs.DirEntry, len(matches))
+	m.matchedIndexes = make(map[string][]int, len(matches))
+	for i, match := range matches {
+		m.files[i] = m.listing[match.Index]
+		m.matchedIndexes[match.Str] = match.MatchedIndexes
+	}
+}
+
+// highlight renders cell, the wrapped name of the n-th file, using style. The
+// file name, and any icon in front of it, is rendered in color where style
+// does not set the same attributes. The characters of the file name matched by
+// the current filter are rendered using the match style instead.
+func (m *Model) highlight(cell string, n int, style, color lipgloss.Style) string {
+	if n >= len(m.files) {
+		return style.Render(cell)
+	}
+	name := m.files[n].Name()
+	at := Index(cell, name)
+	if at < 0 {
+		return m.highlightTruncated(cell, name, style, color)
+	}
+	end := at + len(name)
+	if HasPrefix(cell[end:], fileSeparator) {
+		end += len(fileSeparator)
+	}
+	matched := make(map[int]bool)
+	for _, i := range m.matchedIndexes[name] {
+		matched[at+i] = true
+	}
+	return m.renderMatched(cell, end, matched, style, color)
+}
+
+// renderMatched renders cell using style, the first end bytes of it in color
+// too, except for the bytes at matched, which are rendered using the match
+// style.
+func (m *Model) renderMatched(cell string, end int, matched map[int]bool, style, color lipgloss.Style) string {
+	colored := style.Copy().Inherit(color)
+	match := m.st.Match.Copy().Inherit(colored)
+
+	var sb Builder
+	start := 0
+	for i := range cell[:end] {
+		if i > 0 && matched[i] != matched[start] {
+			sb.WriteString(segment(cell[start:i], matched[start], colored, match))
+			start = i
+		}
+	}
+	sb.WriteString(segment(cell[start:end], matched[start], colored, match))
+	if end < len(cell) {
+		sb.WriteString(style.Render(cell[end:]))
+	}
+	return sb.String()
+}
+
+// highlightTruncated renders cell, whose file name is truncated in the middle,
+// like highlight. The matched characters of the name kept on either side of
+// the ellipsis are rendered using the match style.
+func (m *Model) highlightTruncated(cell, name string, style, color lipgloss.Style) string {
+	matched, end := truncatedMatches(cell, name, m.matchedIndexes[name])
+	if end < 0 {
+		return style.Render(cell)
+	}
+	return m.renderMatched(cell, end, matched, style, color)
+}
+
+// truncatedMatches returns the offsets in cell, in which name is truncated in
+// the middle, of the characters of name at indexes, and the end of the name
+// in cell, or -1 if cell is not truncated.
+func truncatedMatches(cell, name string, indexes []int) (map[int]bool, int) {
+	i := Index(cell, ellipsis)
+	if i < 0 {
+		return nil, -1
+	}
+	// Find where the beginning of the name, kept before the ellipsis, starts
+	// after the icon.
+	head := cell[:i]
+	at := len(head)
+	for k := range head {
+		if HasPrefix(name, head[k:]) {
+			at = k
+			break
+		}
+	}
+	end := i + len(ellipsis)
+	// Find the longest end of the name, or name with separator, kept after
+	// the ellipsis.
+	kept := 0
+	rest := cell[end:]
+	for k := len(rest); k > 0; k-- {
+		tail := rest[:k]
+		if HasSuffix(name, tail) {
+			kept = k
+		} else if HasSuffix(name+fileSeparator, tail) {
+			kept = k - len(fileSeparator)
+		} else {
+			continue
+		}
+		end += k
+		break
+	}
+	tailAt := i + len(ellipsis) - (len(name) - kept)
+	matched := make(map[int]bool)
+	for _, j := range indexes {
+		if j < len(head)-at {
+			matched[at+j] = true
+		} else if j >= len(name)-kept {
+			matched[tailAt+j] = true
+		}
+	}
+	return matched, end
+}
+
+func segment(s string, matched bool, style, match lipgloss.Style) string {
+	if matched {
+		return match.Render(s)
+	}
+	return style.Render(s)
+}
+
+// setSearch sets the filter of the current path and narrows the listing.
+func (m *Model) setSearch(search string) {
+	m.search = search
+	if search == "" {
+		delete(m.filters, m.path)
+	} else {
+		m.filters[m.path] = search
+	}
+	m.applyFilter()
+}
+
+// clearSearch removes the filter of the current path, keeping the cursor on
+// the same file.
+func (m *Model) clearSearch() {
+	fileName, ok := m.fileName()
+	m.searchMode = false
+	m.setSearch("")
+	m.c = 0
+	m.r = 0
+	m.offset = 0
+	if ok {
+		m.prevName = fileName
+		m.findPrevName = true
+	}
+}
+
+// moveMatch moves the cursor by delta matches, wrapping around at both ends.
+func (m *Model) moveMatch(delta int) {
+	if len(m.files) == 0 || m.rows == 0 {
+		return
+	}
+	i := m.c*m.rows + m.r + delta
+	i = (i%len(m.files) + len(m.files)) % len(m.files)
+	m.c = i / m.rows
+	m.r = i % m.rows
+}
+
+// searchTick returns a command that ends type-to-filter input after the
+// configured timeout, or nil if the timeout is disabled.
+func (m *Model) searchTick() tea.Cmd {
+	if m.searchTimeout <= 0 {
+		return nil
+	}
+	// Save search id to clear only current search after delay.
+	// User may have already started typing next search.
+	searchId := m.searchId
+	return tea.Tick(m.searchTimeout, func(time.Time) tea.Msg {
+		return clearSearchMsg(searchId)
+	})
+}
@@ -0,0 +1,36 @@
+package walk
+
+import "testing"
+
+func TestTruncatedMatches(t *testing.T) {
+	tests := []struct {
+		cell, name string
+		indexes    []int
+		want       string // Characters of cell matched.
+	}{
+		{"abc…xyz", "abcdefxyz", []int{0, 2, 4, 7, 8}, "acyz"},
+		{"abc…yz/", "abcdefxyz", []int{1, 8}, "bz"},
+		{"★ ab…yz ", "abcdefxyz", []int{0, 3, 8}, "az"},
+		{"é…ü", "éaü", []int{0, 3}, "éü"},
+		{"abc…-> target", "abcdef", []int{1, 5}, "b"},
+	}
+	for _, tt := range tests {
+		matched, end := truncatedMatches(tt.cell, tt.name, tt.indexes)
+		if end < 0 {
+			t.Errorf("%q: not truncated", tt.cell)
+			continue
+		}
+		got := ""
+		for i, r := range tt.cell {
+			if matched[i] {
+				got += string(r)
+			}
+		}
+		if got != tt.want {
+			t.Errorf("%q matching %v of %q = %q, want %q", tt.cell, tt.indexes, tt.name, got, tt.want)
+		}
+	}
+	if _, end := truncatedMatches("abc", "abc", []int{0}); end >= 0 {
+		t.Errorf("untruncated cell ends name at %d", end)
+	}
+}
@@ -0,0 +1,200 @@
+package walk
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// errReadOnly is returned when modifying files of a file system that does
+// not support it.
+var errReadOnly = errors.New("file system is read-only")
+
+// RemoveFS is a file system whose files can be deleted in a Model.
+type RemoveFS interface {
+	fs.FS
+
+	// RemoveAll removes the file name and any files it contains.
+	RemoveAll(name string) error
+}
+
+// RenameFS is a file system whose files can be renamed in a Model.
+type RenameFS interface {
+	fs.FS
+
+	// Rename renames the file oldname to newname, which does not exist.
+	Rename(oldname, newname string) error
+}
+
+// OSPathFS is a file system whose files are also files of the operating
+// system, which a Model can open with its "open" command.
+type OSPathFS interface {
+	fs.FS
+
+	// OSPath returns the path on the operating system of the file name.
+	OSPath(name string) string
+}
+
+// readLinkFS is a file system with symbolic links, such as fs.ReadLinkFS.
+type readLinkFS interface {
+	fs.FS
+
+	// ReadLink returns the target of the symbolic link name.
+	ReadLink(name string) (string, error)
+
+	// Lstat returns information about the file name, not following symbolic
+	// links.
+	Lstat(name string) (fs.FileInfo, error)
+}
+
+// join joins the elements of a path to a file in the file system browsed:
+// with slashes in a file system given, or with the separator of the operating
+// system otherwise, including in archives.
+func (m *Model) join(elem ...string) string {
+	if m.fsys != nil {
+		return path.Join(elem...)
+	}
+	return filepath.Join(elem...)
+}
+
+// dir returns all but the last element of the path p, like join.
+func (m *Model) dir(p string) string {
+	if m.fsys != nil {
+		return path.Dir(p)
+	}
+	return filepath.Dir(p)
+}
+
+// base returns the last element of the path p, like join.
+func (m *Model) base(p string) string {
+	if m.fsys != nil {
+		return path.Base(p)
+	}
+	return filepath.Base(p)
+}
+
+// fsName returns the file system containing the file at path and the name of
+// the file in it, or nil if it is a file of the operating system.
+func (m *Model) fsName(path string) (fs.FS, string) {
+	if m.fsys != nil {
+		return m.fsys, path
+	}
+	if m.archive != nil {
+		if name, ok := m.archive.name(path); ok {
+			return m.archive.fsys, name
+		}
+	}
+	return nil, path
+}
+
+// osPath returns the path on the operating system of the file at path, if
+// it is not only in the file system browsed.
+func (m *Model) osPath(path string) (string, bool) {
+	if m.fsys != nil {
+		if fsys, ok := m.fsys.(OSPathFS); ok {
+			return fsys.OSPath(path), true
+		}
+		return "", false
+	}
+	if m.archive != nil {
+		if _, ok := m.archive.name(path); ok {
+			return "", false
+		}
+	}
+	return path, true
+}
+
+// readDir reads the directory at path.
+func (m *Model) readDir(path string) ([]fs.DirEntry, error) {
+	if fsys, name := m.fsName(path); fsys != nil {
+		return fs.ReadDir(fsys, name)
+	}
+	return os.ReadDir(path)
+}
+
+// stat returns information about the file at path, following symbolic links.
+func (m *Model) stat(path string) (fs.FileInfo, error) {
+	if fsys, name := m.fsName(path); fsys != nil {
+		return fs.Stat(fsys, name)
+	}
+	return os.Stat(path)
+}
+
+// lstat returns information about the file at path, not following symbolic
+// links, unless the file system browsed does not support them.
+func (m *Model) lstat(path string) (fs.FileInfo, error) {
+	if fsys, name := m.fsName(path); fsys != nil {
+		if fsys, ok := fsys.(readLinkFS); ok {
+			return fsys.Lstat(name)
+		}
+		return fs.Stat(fsys, name)
+	}
+	return os.Lstat(path)
+}
+
+// open opens the file at path for reading.
+func (m *Model) open(path string) (fs.File, error) {
+	if fsys, name := m.fsName(path); fsys != nil {
+		return fsys.Open(name)
+	}
+	return os.Open(path)
+}
+
+// readLink returns the target of the symbolic link at path.
+func (m *Model) readLink(path string) (string, error) {
+	fsys, name := m.fsName(path)
+	switch {
+	case fsys == nil:
+		return os.Readlink(path)
+	case m.fsys == nil:
+		return archiveLink(fsys, name)
+	}
+	if fsys, ok := fsys.(readLinkFS); ok {
+		return fsys.ReadLink(name)
+	}
+	return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+}
+
+// renameFile renames the file at oldPath to newPath.
+func (m *Model) renameFile(oldPath, newPath string) error {
+	if m.fsys == nil {
+		return os.Rename(oldPath, newPath)
+	}
+	if fsys, ok := m.fsys.(RenameFS); ok {
+		return fsys.Rename(oldPath, newPath)
+	}
+	return errReadOnly
+}
+
+// checkWritable returns an error if the file operation bound to msg cannot
+// be done in the file system browsed.
+func (m *Model) checkWritable(msg tea.KeyMsg) error {
+	k := m.keys
+	if !key.Matches(msg, k.Delete, k.Rename, k.BulkRename, k.NewFile, k.NewDir,
+		k.NewSymlink, k.NewHardLink, k.Copy, k.Cut, k.Paste, k.Extract) {
+		return nil
+	}
+	switch {
+	case m.archive != nil:
+		if key.Matches(msg, k.Extract) {
+			return nil
+		}
+		return errArchiveReadOnly
+	case m.fsys == nil:
+		return nil
+	case key.Matches(msg, k.Delete):
+		if _, ok := m.fsys.(RemoveFS); ok {
+			return nil
+		}
+	case key.Matches(msg, k.Rename):
+		if _, ok := m.fsys.(RenameFS); ok {
+			return nil
+		}
+	}
+	return errReadOnly
+}
@@ -0,0 +1,123 @@
+package walk
+
+import (
+	"errors"
+	"io/fs"
+	. "strings"
+	"testing"
+	"testing/fstest"
+)
+
+// moveTo moves the cursor of m to the file named name.
+func moveTo(t *testing.T, m *Model, name string) {
+	t.Helper()
+	m.prevName, m.findPrevName = name, true
+	m.layout(m.listWidth(), m.listHeight())
+	if fileName, _ := m.fileName(); fileName != name {
+		t.Fatalf("cursor on %q, want %q", fileName, name)
+	}
+}
+
+// fileNames returns the names of the files listed by m.
+func fileNames(m *Model) []string {
+	names := make([]string, len(m.files))
+	for i, file := range m.files {
+		names[i] = file.Name()
+	}
+	return names
+}
+
+// testFS returns a file system of a few files, a directory and a link to it.
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"top.txt":   {Data: []byte("top")},
+		"dir/a.txt": {Data: []byte("hello")},
+		"dir/b.txt": {Data: []byte("world")},
+		"link":      {Data: []byte("dir"), Mode: fs.ModeSymlink},
+	}
+}
+
+func TestFS(t *testing.T) {
+	fsys := testFS()
+	m := New(FS(fsys), Size(80, 24))
+	m.Init()
+	if got, want := Join(fileNames(m), " "), "dir link top.txt"; got != want {
+		t.Errorf("files = %q, want %q", got, want)
+	}
+	if got, name := m.fsName("dir/a.txt"); got == nil || name != "dir/a.txt" {
+		t.Errorf("fsName = %v, %q", got, name)
+	}
+	if _, ok := m.osPath("top.txt"); ok {
+		t.Error("file of fstest.MapFS has a path on the operating system")
+	}
+	if target, err := m.readLink("link"); err != nil || target != "dir" {
+		t.Errorf("readLink = %q, %v, want %q", target, err, "dir")
+	}
+
+	moveTo(t, m, "dir")
+	send(m, press("o"))
+	if m.path != "dir" {
+		t.Fatalf("path = %q, want %q", m.path, "dir")
+	}
+	if got, want := Join(fileNames(m), " "), "a.txt b.txt"; got != want {
+		t.Errorf("files = %q, want %q", got, want)
+	}
+
+	send(m, press("`"))
+	if view := m.previewView(); !Contains(view, "hello") {
+		t.Errorf("preview = %q, want content of a.txt", view)
+	}
+	send(m, press("`"))
+
+	send(m, press("b"))
+	if m.path != "." {
+		t.Errorf("path = %q, want %q", m.path, ".")
+	}
+}
+
+func TestFSReadOnly(t *testing.T) {
+	fsys := testFS()
+	m := New(FS(fsys), Size(80, 24))
+	m.Init()
+	moveTo(t, m, "top.txt")
+	for _, k := range []string{"d", "r", "a", "A", "L", "H", "c", "x", "P"} {
+		send(m, press(k))
+		if !errors.Is(m.opErr, errReadOnly) {
+			t.Errorf("%v: error = %v, want %v", k, m.opErr, errReadOnly)
+		}
+	}
+	if _, ok := fsys["top.txt"]; !ok {
+		t.Error("file removed")
+	}
+}
+
+// removeFS is a fstest.MapFS whose files can be removed.
+type removeFS struct{ fstest.MapFS }
+
+func (f removeFS) RemoveAll(name string) error {
+	for path := range f.MapFS {
+		if path == name || HasPrefix(path, name+"/") {
+			delete(f.MapFS, path)
+		}
+	}
+	return nil
+}
+
+func TestFSRemove(t *testing.T) {
+	fsys := removeFS{testFS()}
+	m := New(FS(fsys), Size(80, 24))
+	m.Init()
+	moveTo(t, m, "top.txt")
+	if err := m.checkWritable(press("d")); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := m.checkWritable(press("r")); !errors.Is(err, errReadOnly) {
+		t.Errorf("rename: error = %v, want %v", err, errReadOnly)
+	}
+	if err := m.remove("top.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fsys.MapFS["top.txt"]; ok {
+		t.Error("file not removed")
+	}
+}
@@ -0,0 +1,365 @@
+package walk
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	. "strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nfnt/resize"
+)
+
+// ImageProtocol identifies how images are drawn in the preview pane.
+type ImageProtocol int
+
+// Image protocols. Terminals not supporting the protocol selected show the
+// image as half-block characters instead.
+const (
+	ImageAuto   ImageProtocol = iota // Detected from the terminal environment.
+	ImageBlocks                      // Half-block characters, one color each.
+	ImageKitty                       // Kitty graphics protocol.
+	ImageSixel                       // Sixel graphics.
+	imageProtocolCount
+)
+
+func (p ImageProtocol) String() string {
+	switch p {
+	case ImageAuto:
+		return "auto"
+	case ImageBlocks:
+		return "blocks"
+	case ImageKitty:
+		return "kitty"
+	case ImageSixel:
+		return "sixel"
+	}
+	return "unknown"
+}
+
+// ParseImageProtocol parses the name of an image protocol, e.g., "sixel".
+func ParseImageProtocol(s string) (ImageProtocol, error) {
+	for p := ImageProtocol(0); p < imageProtocolCount; p++ {
+		if EqualFold(TrimSpace(s), p.String()) {
+			return p, nil
+		}
+	}
+	return ImageAuto, fmt.Errorf("unknown image protocol: %q", s)
+}
+
+// detectImageProtocol returns the image protocol supported by the terminal,
+// as told by its environment.
+func detectImageProtocol() ImageProtocol {
+	term, program := os.Getenv("TERM"), os.Getenv("TERM_PROGRAM")
+	switch {
+	case os.Getenv("TMUX") != "" || HasPrefix(term, "screen") || HasPrefix(term, "tmux"):
+		// Graphics would have to be passed through the multiplexer.
+		return ImageBlocks
+	case term == "xterm-kitty" || os.Getenv("KITTY_WINDOW_ID") != "" ||
+		term == "xterm-ghostty" || program == "ghostty":
+		return ImageKitty
+	case program == "WezTerm" || program == "iTerm.app" || program == "mlterm" ||
+		HasPrefix(term, "foot") || HasPrefix(term, "mlterm") || HasPrefix(term, "yaft") ||
+		Contains(term, "sixel"):
+		return ImageSixel
+	}
+	return ImageBlocks
+}
+
+// Cell size assumed if the terminal does not report it.
+const (
+	defaultCellWidth  = 10
+	defaultCellHeight = 20
+)
+
+// graphicDelay is the delay before drawing Sixel graphics, so that they are
+// drawn over the lines just rendered.
+const graphicDelay = 50 * time.Millisecond
+
+// imagePreview sets the lines of b to the image file, drawn in at most
+// width x height cells with the image protocol of the source, below a header
+// describing its format and size. If ctx is cancelled meanwhile, it stops
+// between stages and the preview returned is incomplete.
+func (src *previewSource) imagePreview(ctx context.Context, b *previewBuffer, config image.Config, format string, width, height int) *previewBuffer {
+	b.header = src.st.Header.Render(fmt.Sprintf("%v image, %d×%d, %v",
+		ToUpper(format), config.Width, config.Height, humanSize(b.key.size)))
+	height = max(height-1, 1) // Subtract 1 for header.
+	img, err := src.decodeImage()
+	if err != nil {
+		b.lines = Split(src.st.Warning.Render("No image preview available"), "\n")
+		return b
+	}
+	if ctx.Err() != nil {
+		return b
+	}
+	protocol := src.protocol
+	width -= src.st.Preview.GetPaddingLeft()
+	cellWidth, cellHeight := src.cellWidth, src.cellHeight
+	if protocol == ImageBlocks {
+		drawn := drawImage(img, width, height, cellWidth, cellHeight)
+		b.lines = Split(TrimSuffix(drawn, "\n"), "\n")
+		b.plain = make([]string, len(b.lines))
+		return b
+	}
+
+	if protocol == ImageKitty {
+		height = min(height, len(kittyDiacritics))
+	}
+	img = fitImage(img, width*cellWidth, height*cellHeight)
+	if ctx.Err() != nil {
+		return b
+	}
+	bounds := img.Bounds()
+	columns := max((bounds.Dx()+cellWidth-1)/cellWidth, 1)
+	rows := max((bounds.Dy()+cellHeight-1)/cellHeight, 1)
+
+	if protocol == ImageKitty {
+		b.graphic, b.imageId, b.lines, err = kittyImage(img, columns, rows)
+		if err != nil {
+			b.lines = Split(src.st.Warning.Render("No image preview available"), "\n")
+		}
+	} else {
+		b.graphic, b.sixel = sixelImage(img), true
+		b.lines = make([]string, rows)
+		for i := range b.lines {
+			b.lines[i] = Repeat(" ", columns)
+		}
+	}
+	b.plain = make([]string, len(b.lines))
+	return b
+}
+
+// fitImage scales img down to fit in width x height pixels, preserving its
+// aspect ratio.
+func fitImage(img image.Image, width, height int) image.Image {
+	bounds := img.Bounds()
+	if bounds.Dx() <= width && bounds.Dy() <= height {
+		return img
+	}
+	scale := math.Min(float64(width)/float64(bounds.Dx()), float64(height)/float64(bounds.Dy()))
+	return resize.Resize(
+		uint(max(int(float64(bounds.Dx())*scale), 1)),
+		uint(max(int(float64(bounds.Dy())*scale), 1)),
+		img, resize.Lanczos3)
+}
+
+// syncWriter serializes the writes to w. The Bubble Tea renderer writes each
+// frame at once, so images written meanwhile are drawn between frames, not
+// in the middle of one.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// drawGraphic returns a command drawing the image shown in the preview pane
+// with a graphics protocol, if any. Kitty images are transmitted once, and
+// shown by the Unicode placeholders in the preview lines. Sixel graphics are
+// drawn over the preview lines, again after each update, as the lines being
+// rendered erase them. Images are written in a single write to the output,
+// which the program must render to as well.
+func (m *Model) drawGraphic() tea.Cmd {
+	b := m.shownPreview()
+	if b == nil || b.graphic == "" || !b.sixel && m.graphicShown == b {
+		return nil
+	}
+	// Only the last kitty image is kept by the terminal; it is transmitted
+	// again if shown again.
+	deleted := ""
+	if prev := m.graphicShown; prev != nil && prev.imageId != 0 {
+		deleted = kittyDelete(prev.imageId)
+	}
+	m.graphicShown = b
+	out := m.output
+	if !b.sixel {
+		return func() tea.Msg {
+			_, _ = fmt.Fprint(out, deleted+b.graphic)
+			return nil
+		}
+	}
+	row := 2 // Below name bar.
+	if b.header != "" {
+		row++
+	}
+	column := m.previewColumn + 1
+	return tea.Tick(graphicDelay, func(time.Time) tea.Msg {
+		// Save cursor position, draw at the preview pane, restore position.
+		_, _ = fmt.Fprintf(out, "%v\x1b7\x1b[%d;%dH%v\x1b8", deleted, row, column, b.graphic)
+		return nil
+	})
+}
+
+// kittyChunkSize is the size of the chunks of base64-encoded image data sent
+// with the kitty graphics protocol.
+const kittyChunkSize = 4096
+
+// kittyPlaceholder is the character whose cells display a kitty image.
+const kittyPlaceholder = '\U0010EEEE'
+
+// kittyDiacritics encode the row and column of a placeholder cell in a kitty
+// image, as listed in the kitty graphics protocol specification.
+var kittyDiacritics = []rune{
+	0x0305, 0x030D, 0x030E, 0x0310, 0x0312, 0x033D, 0x033E, 0x033F,
+	0x0346, 0x034A, 0x034B, 0x034C, 0x0350, 0x0351, 0x0352, 0x0357,
+	0x035B, 0x0363, 0x0364, 0x0365, 0x0366, 0x0367, 0x0368, 0x0369,
+	0x036A, 0x036B, 0x036C, 0x036D, 0x036E, 0x036F, 0x0483, 0x0484,
+	0x0485, 0x0486, 0x0487, 0x0592, 0x0593, 0x0594, 0x0595, 0x0597,
+	0x0598, 0x0599, 0x059C, 0x059D, 0x059E, 0x059F, 0x05A0, 0x05A1,
+	0x05A8, 0x05A9, 0x05AB, 0x05AC, 0x05AF, 0x05C4, 0x0610, 0x0611,
+	0x0612, 0x0613, 0x0614, 0x0615, 0x0616, 0x0617, 0x0657, 0x0658,
+	0x0659, 0x065A, 0x065B, 0x065D, 0x065E, 0x06D6, 0x06D7, 0x06D8,
+	0x06D9, 0x06DA, 0x06DB, 0x06DC, 0x06DF, 0x06E0, 0x06E1, 0x06E2,
+	0x06E4, 0x06E7, 0x06E8, 0x06EB, 0x06EC, 0x0730, 0x0732, 0x0733,
+	0x0735, 0x0736, 0x073A, 0x073D, 0x073F, 0x0740, 0x0741, 0x0743,
+	0x0745, 0x0747, 0x0749, 0x074A, 0x07EB, 0x07EC, 0x07ED, 0x07EE,
+	0x07EF, 0x07F0, 0x07F1, 0x07F3, 0x0816, 0x0817, 0x0818, 0x0819,
+	0x081B, 0x081C, 0x081D, 0x081E, 0x081F, 0x0820, 0x0821, 0x0822,
+	0x0823, 0x0825, 0x0826, 0x0827, 0x0829, 0x082A, 0x082B, 0x082C,
+	0x082D,
+}
+
+// lastKittyImageId is the id of the last image transmitted to the terminal.
+var lastKittyImageId uint32
+
+// kittyImage returns the escape sequences transmitting img to the terminal
+// with the kitty graphics protocol, its id, and the lines of Unicode
+// placeholders displaying it in columns x rows cells.
+func kittyImage(img image.Image, columns, rows int) (string, uint32, []string, error) {
+	var data bytes.Buffer
+	if err := png.Encode(&data, img); err != nil {
+		return "", 0, nil, err
+	}
+	// The id is encoded in the 24-bit foreground color of placeholders.
+	id := atomic.AddUint32(&lastKittyImageId, 1)%0xFFFFFF + 1
+
+	payload := base64.StdEncoding.EncodeToString(data.Bytes())
+	var sb Builder
+	for i := 0; i < len(payload); i += kittyChunkSize {
+		more := 0
+		if i+kittyChunkSize < len(payload) {
+			more = 1
+		}
+		chunk := payload[i:min(i+kittyChunkSize, len(payload))]
+		if i == 0 {
+			// Transmit and create a virtual placement, quietly.
+			fmt.Fprintf(&sb, "\x1b_Ga=T,U=1,f=100,q=2,i=%d,c=%d,r=%d,m=%d;%v\x1b\\",
+				id, columns, rows, more, chunk)
+		} else {
+			fmt.Fprintf(&sb, "\x1b_Gm=%d;%v\x1b\\", more, chunk)
+		}
+	}
+
+	color := fmt.Sprintf("\x1b[38;2;%d;%d;%dm", id>>16&0xFF, id>>8&0xFF, id&0xFF)
+	lines := make([]string, rows)
+	for r := range lines {
+		// Following cells continue the row and column of the first.
+		lines[r] = color + string([]rune{kittyPlaceholder, kittyDiacritics[r], kittyDiacritics[0]}) +
+			Repeat(string(kittyPlaceholder), columns-1) + "\x1b[39m"
+	}
+	return sb.String(), id, lines, nil
+}
+
+// kittyDelete returns the escape sequence deleting the kitty image id, and
+// freeing its data.
+func kittyDelete(id uint32) string {
+	return fmt.Sprintf("\x1b_Ga=d,d=I,q=2,i=%d\x1b\\", id)
+}
+
+// exit returns the command quitting the program, once the kitty image drawn
+// last, if any, is deleted.
+func (m *Model) exit() tea.Cmd {
+	b := m.graphicShown
+	if b == nil || b.imageId == 0 {
+		return tea.Quit
+	}
+	out := m.output
+	return tea.Sequence(func() tea.Msg {
+		_, _ = fmt.Fprint(out, kittyDelete(b.imageId))
+		return nil
+	}, tea.Quit)
+}
+
+// sixelImage returns img encoded as Sixel graphics, dithered to at most 256
+// colors. Mostly transparent pixels are left unset.
+func sixelImage(img image.Image) string {
+	bounds := img.Bounds()
+	paletted := image.NewPaletted(bounds, palette.Plan9)
+	draw.FloydSteinberg.Draw(paletted, bounds, img, bounds.Min)
+	width, height := bounds.Dx(), bounds.Dy()
+
+	var sb Builder
+	// Pixel aspect ratio 1:1, and transparent background.
+	fmt.Fprintf(&sb, "\x1bP0;1;0q\"1;1;%d;%d", width, height)
+	for i, c := range paletted.Palette {
+		r, g, b, _ := c.RGBA()
+		fmt.Fprintf(&sb, "#%d;2;%d;%d;%d", i, r*100/0xFFFF, g*100/0xFFFF, b*100/0xFFFF)
+	}
+	// Index of the color of each pixel, or -1 if mostly transparent.
+	indexes := make([]int, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			indexes[y*width+x] = -1
+			if _, _, _, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA(); a >= 0x8000 {
+				indexes[y*width+x] = int(paletted.ColorIndexAt(bounds.Min.X+x, bounds.Min.Y+y))
+			}
+		}
+	}
+	// Each band of six rows of pixels is drawn once per color used.
+	sixels := make([]byte, width)
+	for y0 := 0; y0 < height; y0 += 6 {
+		var used [256]bool
+		for i := y0 * width; i < min(y0+6, height)*width; i++ {
+			if indexes[i] >= 0 {
+				used[indexes[i]] = true
+			}
+		}
+		for c := range used {
+			if !used[c] {
+				continue
+			}
+			for x := 0; x < width; x++ {
+				bits := byte(0)
+				for k := 0; k < 6 && y0+k < height; k++ {
+					if indexes[(y0+k)*width+x] == c {
+						bits |= 1 << k
+					}
+				}
+				sixels[x] = '?' + bits
+			}
+			fmt.Fprintf(&sb, "#%d", c)
+			// Run-length encode repeated sixels.
+			for x := 0; x < width; {
+				n := 1
+				for x+n < width && sixels[x+n] == sixels[x] {
+					n++
+				}
+				if n > 3 {
+					fmt.Fprintf(&sb, "!%d%c", n, sixels[x])
+				} else {
+					sb.WriteString(Repeat(string(sixels[x]), n))
+				}
+				x += n
+			}
+			sb.WriteByte('$')
+		}
+		sb.WriteByte('-')
+	}
+	sb.WriteString("\x1b\\")
+	return sb.String()
+}
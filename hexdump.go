@@ -0,0 +1,165 @@
+package walk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	. "strings"
+)
+
+// magic is a signature identifying a file type by its leading bytes.
+type magic struct {
+	offset int    // Offset of signature in file.
+	sig    string // Signature bytes.
+	name   string // Description of file type.
+}
+
+// magics are the signatures of well-known binary file types. More specific
+// signatures precede any signature they start with.
+var magics = []magic{
+	{0, "\x7fELF", "ELF"},
+	{0, "MZ", "DOS executable"},
+	{0, "\xfe\xed\xfa\xce", "Mach-O 32-bit (big-endian)"},
+	{0, "\xce\xfa\xed\xfe", "Mach-O 32-bit"},
+	{0, "\xfe\xed\xfa\xcf", "Mach-O 64-bit (big-endian)"},
+	{0, "\xcf\xfa\xed\xfe", "Mach-O 64-bit"},
+	{0, "\xca\xfe\xba\xbe", "Mach-O universal binary"},
+	{0, "\x00asm", "WebAssembly binary"},
+	{0, "!<arch>\ndebian-binary", "Debian package"},
+	{0, "!<arch>\n", "ar archive"},
+	{0, "\xed\xab\xee\xdb", "RPM package"},
+	{0, "PK\x03\x04", "Zip archive"},
+	{0, "PK\x05\x06", "Zip archive (empty)"},
+	{257, "ustar", "tar archive"},
+	{0, "\x1f\x8b", "gzip compressed data"},
+	{0, "BZh", "bzip2 compressed data"},
+	{0, "\xfd7zXZ\x00", "xz compressed data"},
+	{0, "\x28\xb5\x2f\xfd", "Zstandard compressed data"},
+	{0, "\x04\x22\x4d\x18", "LZ4 compressed data"},
+	{0, "7z\xbc\xaf\x27\x1c", "7-Zip archive"},
+	{0, "Rar!\x1a\x07", "RAR archive"},
+	{0, "MSCF", "Microsoft Cabinet archive"},
+	{0, "%PDF-", "PDF document"},
+	{0, "\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1", "Microsoft Office document"},
+	{0, "SQLite format 3\x00", "SQLite database"},
+	{0, "\x89PNG\r\n\x1a\n", "PNG image"},
+	{0, "\xff\xd8\xff", "JPEG image"},
+	{0, "GIF87a", "GIF image"},
+	{0, "GIF89a", "GIF image"},
+	{0, "BM", "BMP image"},
+	{0, "II*\x00", "TIFF image"},
+	{0, "MM\x00*", "TIFF image"},
+	{0, "\x00\x00\x01\x00", "ICO image"},
+	{8, "WEBP", "WebP image"},
+	{8, "WAVE", "WAVE audio"},
+	{8, "AVI ", "AVI video"},
+	{0, "OggS", "Ogg media"},
+	{0, "fLaC", "FLAC audio"},
+	{0, "ID3", "MP3 audio"},
+	{0, "\x1a\x45\xdf\xa3", "Matroska/WebM video"},
+	{4, "ftyp", "ISO media (MP4)"},
+	{0, "wOFF", "WOFF font"},
+	{0, "wOF2", "WOFF2 font"},
+	{0, "\x00\x01\x00\x00\x00", "TrueType font"},
+	{0, "OTTO", "OpenType font"},
+	{0, "\xca\xfe\xd0\x0d", "Java pack200 archive"},
+	{0, "\xac\xed", "Java serialized data"},
+}
+
+// fileType describes the type of file whose leading bytes are header, or
+// returns "binary data" if it is not recognized.
+func fileType(header []byte) string {
+	for _, magic := range magics {
+		if !bytes.HasPrefix(header[min(magic.offset, len(header)):], []byte(magic.sig)) {
+			continue
+		}
+		switch magic.sig {
+		case "\x7fELF":
+			return elfType(header)
+		case "MZ":
+			// A PE header follows the DOS stub at the offset stored at 0x3c.
+			if len(header) >= 0x40 {
+				pe := int(binary.LittleEndian.Uint32(header[0x3c:]))
+				if pe >= 0x40 && pe+4 <= len(header) && string(header[pe:pe+4]) == "PE\x00\x00" {
+					return "PE executable"
+				}
+			}
+		case "\xca\xfe\xba\xbe":
+			// Java class files share the signature; their version is >= 45
+			// where universal binaries store a small number of architectures.
+			if len(header) >= 8 && binary.BigEndian.Uint32(header[4:]) >= 45 {
+				return "Java class file"
+			}
+		}
+		return magic.name
+	}
+	return "binary data"
+}
+
+// elfType describes the ELF file whose leading bytes are header.
+func elfType(header []byte) string {
+	if len(header) < 18 {
+		return "ELF"
+	}
+	class := map[byte]string{1: "32-bit", 2: "64-bit"}[header[4]]
+	var order binary.ByteOrder = binary.LittleEndian
+	if header[5] == 2 {
+		order = binary.BigEndian
+	}
+	kind := map[uint16]string{
+		1: "relocatable", 2: "executable", 3: "shared object", 4: "core file",
+	}[order.Uint16(header[16:])]
+	desc := "ELF"
+	for _, s := range []string{class, kind} {
+		if s != "" {
+			desc += " " + s
+		}
+	}
+	return desc
+}
+
+// hexBytesPerLine returns the number of bytes per line of a hex dump that
+// fits in width: 16 as xxd does, or fewer in narrow panes.
+func hexBytesPerLine(width int) int {
+	n := 16
+	for n > 2 && hexLineWidth(n) > width {
+		n /= 2
+	}
+	return n
+}
+
+// hexLineWidth returns the width of a hex dump line showing n bytes: offset,
+// bytes in groups of two, and their ASCII representation.
+func hexLineWidth(n int) int {
+	return len("00000000: ") + n*2 + n/2 - 1 + 2 + n
+}
+
+// hexLines returns the lines of an xxd-style hex dump of chunk, the bytes
+// at offset in a file, showing n bytes per line. The lines are returned with
+// and without styling.
+func (src *previewSource) hexLines(chunk []byte, offset int64, n int) (lines, plain []string) {
+	for i := 0; i < len(chunk); i += n {
+		line := chunk[i:min(i+n, len(chunk))]
+		var hex, ascii Builder
+		for j := 0; j < n; j++ {
+			if j > 0 && j%2 == 0 {
+				hex.WriteByte(' ')
+			}
+			if j >= len(line) {
+				hex.WriteString("  ")
+				continue
+			}
+			fmt.Fprintf(&hex, "%02x", line[j])
+			if b := line[j]; b >= 0x20 && b < 0x7f {
+				ascii.WriteByte(b)
+			} else {
+				ascii.WriteByte('.')
+			}
+		}
+		address := fmt.Sprintf("%08x:", offset+int64(i))
+		dump := " " + hex.String() + "  " + ascii.String()
+		lines = append(lines, src.st.LineNumber.Render(address)+dump)
+		plain = append(plain, address+dump)
+	}
+	return lines, plain
+}
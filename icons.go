@@ -3,9 +3,10 @@ package walk
 import (
 	"bufio"
 	"embed"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"io/fs"
 	"os"
 	"os/user"
 	"path/filepath"
@@ -13,25 +14,73 @@ import (
 	"unicode"
 )
 
+// IconResolver returns the icons of files listed by a Model.
+type IconResolver interface {
+	// Icon returns the icon of the file described by info, which is a
+	// symbolic link to a file that does not exist if orphan, or "" for none.
+	// The path is that of the file on the operating system, if any.
+	Icon(path string, info fs.FileInfo, orphan bool) string
+}
+
+// IconResolverFunc is a function used as an IconResolver.
+type IconResolverFunc func(path string, info fs.FileInfo, orphan bool) string
+
+// Icon returns f(path, info, orphan).
+func (f IconResolverFunc) Icon(path string, info fs.FileInfo, orphan bool) string {
+	return f(path, info, orphan)
+}
+
+// iconMap is an IconResolver of icons by file keys, as read from icons files.
 type iconMap map[string]string
 
-var icons iconMap
+// iconsEnv is the environment variable setting icons over those of icon
+// files, in the format of LS_COLORS, e.g., "di=D:*.go=G".
+const iconsEnv = "LK_ICONS"
 
-func parseIcons() {
-	icons = make(iconMap)
-	icons.parse()
+// loadIcons returns the icons of the embedded icons file, merged with those
+// of the user icons file and iconsEnv, and warnings about any entries that
+// cannot be parsed.
+func loadIcons() (iconMap, []error) {
+	icons := make(iconMap)
+	return icons, icons.parse()
 }
 
 //go:embed etc/icons
 var f embed.FS
 
-func (im iconMap) parse() {
-	icons, _ := f.Open("etc/icons")
-	pairs, err := readPairs(icons)
-	if err != nil {
-		log.Printf("reading icons file: %s", err)
-		return
+func (im iconMap) parse() []error {
+	defaults, _ := f.Open("etc/icons")
+	pairs, warnings := readPairs(defaults, "etc/icons")
+	im.add(pairs)
+
+	if path, err := userIconsPath(); err != nil {
+		warnings = append(warnings, err)
+	} else if file, err := os.Open(path); err == nil {
+		pairs, errs := readPairs(file, path)
+		file.Close()
+		im.add(pairs)
+		warnings = append(warnings, errs...)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		warnings = append(warnings, err)
+	}
+
+	for _, entry := range strings.Split(os.Getenv(iconsEnv), ":") {
+		if entry == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(entry, "=")
+		if !ok {
+			warnings = append(warnings,
+				fmt.Errorf("%v: expected key=icon but found: %s", iconsEnv, entry))
+			continue
+		}
+		im.add([][]string{{key, val}})
 	}
+	return warnings
+}
+
+// add sets the icons of the given key and icon pairs.
+func (im iconMap) add(pairs [][]string) {
 	for _, pair := range pairs {
 		key, val := pair[0], pair[1]
 		key = replaceTilde(key)
@@ -42,83 +91,105 @@ func (im iconMap) parse() {
 	}
 }
 
-func (im iconMap) getIcon(f os.FileInfo) string {
-	if f.IsDir() {
-		if val, ok := im[f.Name()+"/"]; ok {
+// userIconsPath returns the path of the user icons file, which overrides the
+// embedded icons.
+func userIconsPath() (string, error) {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "lk", "icons"), nil
+}
+
+// Icon returns the icon of the file described by f, which is a symbolic link
+// to a file that does not exist if orphan. Icons set for the absolute path of
+// the file, if any, take precedence.
+func (im iconMap) Icon(path string, f fs.FileInfo, orphan bool) string {
+	if val, ok := im[path]; ok && path != "" {
+		return val
+	}
+	for _, key := range fileKeys(f, orphan) {
+		if val, ok := im[key]; ok {
 			return val
 		}
 	}
-	var key string
+	return " "
+}
+
+// fileKeys returns the keys that may describe the file f in an icons file or
+// LS_COLORS, from the most specific to the least, i.e., its name, its type,
+// its name patterns and its extension. If orphan, f is a symbolic link to a
+// file that does not exist.
+func fileKeys(f os.FileInfo, orphan bool) []string {
+	var keys []string
+	if f.IsDir() {
+		keys = append(keys, f.Name()+"/")
+	}
 	switch {
+	case f.Mode()&os.ModeSymlink != 0 && orphan:
+		keys = append(keys, "or")
+	case f.Mode()&os.ModeSymlink != 0:
+		keys = append(keys, "ln")
 	case f.IsDir() && f.Mode()&os.ModeSticky != 0 && f.Mode()&0o002 != 0:
-		key = "tw"
+		keys = append(keys, "tw")
 	case f.IsDir() && f.Mode()&0o002 != 0:
-		key = "ow"
+		keys = append(keys, "ow")
 	case f.IsDir() && f.Mode()&os.ModeSticky != 0:
-		key = "st"
+		keys = append(keys, "st")
 	case f.IsDir():
-		key = "di"
+		keys = append(keys, "di")
 	case f.Mode()&os.ModeNamedPipe != 0:
-		key = "pi"
+		keys = append(keys, "pi")
 	case f.Mode()&os.ModeSocket != 0:
-		key = "so"
-	case f.Mode()&os.ModeDevice != 0:
-		key = "bd"
+		keys = append(keys, "so")
 	case f.Mode()&os.ModeCharDevice != 0:
-		key = "cd"
+		keys = append(keys, "cd")
+	case f.Mode()&os.ModeDevice != 0:
+		keys = append(keys, "bd")
 	case f.Mode()&os.ModeSetuid != 0:
-		key = "su"
+		keys = append(keys, "su")
 	case f.Mode()&os.ModeSetgid != 0:
-		key = "sg"
+		keys = append(keys, "sg")
 	case f.Mode()&0o111 != 0:
-		key = "ex"
-	}
-	if val, ok := im[key]; ok {
-		return val
-	}
-	if val, ok := im[f.Name()+"*"]; ok {
-		return val
-	}
-	if val, ok := im["*"+f.Name()]; ok {
-		return val
-	}
-	if val, ok := im[filepath.Base(f.Name())+".*"]; ok {
-		return val
-	}
-	ext := filepath.Ext(f.Name())
-	if val, ok := im["*"+strings.ToLower(ext)]; ok {
-		return val
+		keys = append(keys, "ex")
 	}
+	keys = append(keys,
+		f.Name()+"*",
+		"*"+f.Name(),
+		filepath.Base(f.Name())+".*",
+		"*"+strings.ToLower(filepath.Ext(f.Name())),
+	)
 	if f.Mode()&0o111 != 0 {
-		if val, ok := im["ex"]; ok {
-			return val
-		}
-	}
-	if val, ok := im["fi"]; ok {
-		return val
+		keys = append(keys, "ex")
 	}
-	return " "
+	return append(keys, "fi")
 }
 
 func replaceTilde(s string) string {
+	if !strings.HasPrefix(s, "~") {
+		return s
+	}
 	u, err := user.Current()
 	if err != nil {
-		log.Printf("user: %s", err)
-	}
-	if strings.HasPrefix(s, "~") {
-		s = strings.Replace(s, "~", u.HomeDir, 1)
+		return s
 	}
-	return s
+	return strings.Replace(s, "~", u.HomeDir, 1)
 }
 
 // This function reads whitespace separated string pairs at each line. Single
 // or double quotes can be used to escape whitespaces. Hash characters can be
 // used to add a comment until the end of line. Leading and trailing space is
-// trimmed. Empty lines are skipped.
-func readPairs(r io.Reader) ([][]string, error) {
+// trimmed. Empty lines are skipped. Lines that are not pairs are skipped too,
+// and reported as errors located in the file named name.
+func readPairs(r io.Reader, name string) ([][]string, []error) {
 	var pairs [][]string
+	var errs []error
 	s := bufio.NewScanner(r)
-	for s.Scan() {
+	for n := 1; s.Scan(); n++ {
 		line := s.Text()
 
 		squote, dquote := false, false
@@ -151,7 +222,8 @@ func readPairs(r io.Reader) ([][]string, error) {
 		})
 
 		if len(pair) != 2 {
-			return nil, fmt.Errorf("expected pair but found: %s", s.Text())
+			errs = append(errs, fmt.Errorf("%v:%d: expected pair but found: %s", name, n, s.Text()))
+			continue
 		}
 
 		for i := 0; i < len(pair); i++ {
@@ -173,6 +245,9 @@ func readPairs(r io.Reader) ([][]string, error) {
 
 		pairs = append(pairs, pair)
 	}
+	if err := s.Err(); err != nil {
+		errs = append(errs, fmt.Errorf("%v: %w", name, err))
+	}
 
-	return pairs, nil
+	return pairs, errs
 }
@@ -0,0 +1,172 @@
+package walk
+
+import (
+	"bufio"
+	"io/fs"
+	"path"
+	"path/filepath"
+	. "strings"
+)
+
+// ignoreFileNames are the names of files containing ignore patterns honored
+// by a Model configured with IgnoreFiles.
+var ignoreFileNames = []string{".gitignore", ".ignore"}
+
+// ignoreRule is a single pattern using the syntax of .gitignore files.
+type ignoreRule struct {
+	base     string // Directory to which anchored patterns are relative.
+	pattern  string // Glob pattern, with "**" matching any number of dirs.
+	negate   bool   // Pattern starts with "!" and re-includes matches.
+	dirOnly  bool   // Pattern ends with "/" and matches only directories.
+	anchored bool   // Pattern contains "/" and is matched against the path.
+}
+
+// parseIgnoreRule parses a line of an ignore file located in directory base.
+// It returns false if the line is blank or a comment.
+func parseIgnoreRule(base, line string) (ignoreRule, bool) {
+	line = TrimRight(line, " \t\r")
+	if line == "" || HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+	rule := ignoreRule{base: base}
+	if HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if HasPrefix(line, `\`) {
+		line = line[1:] // Escaped leading "!" or "#".
+	}
+	if HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = TrimRight(line, "/")
+	}
+	if Contains(line, "/") {
+		rule.anchored = true
+		line = TrimLeft(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+	rule.pattern = line
+	return rule, true
+}
+
+// match reports whether the rule matches the file at absolute path name.
+func (r ignoreRule) match(name string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if !r.anchored {
+		ok, _ := path.Match(r.pattern, path.Base(filepath.ToSlash(name)))
+		return ok
+	}
+	rel, err := filepath.Rel(r.base, name)
+	if err != nil || HasPrefix(rel, "..") {
+		return false
+	}
+	return globMatch(
+		Split(r.pattern, "/"),
+		Split(filepath.ToSlash(rel), "/"),
+	)
+}
+
+// globMatch matches path elements against pattern elements, where a "**"
+// pattern element matches zero or more path elements.
+func globMatch(pattern, elems []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(elems); i++ {
+				if globMatch(pattern[1:], elems[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(elems) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], elems[0]); !ok {
+			return false
+		}
+		pattern, elems = pattern[1:], elems[1:]
+	}
+	return len(elems) == 0
+}
+
+// readIgnoreRules returns the rules of the ignore files found in dir and each
+// of its parents, up to and including the root of a git work tree. Rules from
+// deeper directories come last, so that they take precedence.
+func (m *Model) readIgnoreRules(dir string) []ignoreRule {
+	var dirs []string
+	for dir = m.join(dir); ; dir = m.dir(dir) {
+		dirs = append(dirs, dir)
+		if _, err := m.stat(m.join(dir, ".git")); err == nil {
+			break
+		}
+		if dir == m.dir(dir) {
+			break
+		}
+	}
+	var rules []ignoreRule
+	for i := len(dirs) - 1; i >= 0; i-- {
+		for _, name := range ignoreFileNames {
+			rules = append(rules, m.readIgnoreFile(dirs[i], name)...)
+		}
+	}
+	return rules
+}
+
+func (m *Model) readIgnoreFile(dir, name string) []ignoreRule {
+	file, err := m.open(m.join(dir, name))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	var rules []ignoreRule
+	s := bufio.NewScanner(file)
+	for s.Scan() {
+		if rule, ok := parseIgnoreRule(dir, s.Text()); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// ignored reports whether the file at absolute path name is matched by rules.
+// The last matching rule decides, so that negated rules can re-include files.
+func ignored(rules []ignoreRule, name string, isDir bool) bool {
+	ignore := false
+	for _, rule := range rules {
+		if rule.match(name, isDir) {
+			ignore = !rule.negate
+		}
+	}
+	return ignore
+}
+
+// isHidden reports whether name is a dotfile.
+func isHidden(name string) bool {
+	return HasPrefix(name, ".")
+}
+
+// visible returns the files in dir that are neither hidden nor ignored,
+// unless the receiver is configured to show hidden files.
+func (m *Model) visible(dir string, files []fs.DirEntry) []fs.DirEntry {
+	if m.showHidden {
+		return files
+	}
+	rules := m.ignore
+	if m.ignoreFiles {
+		rules = append(rules[:len(rules):len(rules)], m.readIgnoreRules(dir)...)
+	}
+	var result []fs.DirEntry
+	for _, file := range files {
+		if isHidden(file.Name()) {
+			continue
+		}
+		if ignored(rules, m.join(dir, file.Name()), file.IsDir()) {
+			continue
+		}
+		result = append(result, file)
+	}
+	return result
+}
@@ -0,0 +1,69 @@
+package walk
+
+import (
+	"os"
+	"path/filepath"
+	. "strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"build", "build", true},
+		{"build", "sub/build", false},
+		{"*.go", "main.go", true},
+		{"*.go", "sub/main.go", false},
+		{"sub/*.go", "sub/main.go", true},
+		{"**/build", "build", true},
+		{"**/build", "a/b/build", true},
+		{"a/**", "a/b/c", true},
+		{"a/**", "a", true},
+		{"a/**/z", "a/z", true},
+		{"a/**/z", "a/b/c/z", true},
+		{"a/**/z", "a/b/c", false},
+		{"a/?", "a/bc", false},
+	}
+	for _, tt := range tests {
+		if got := globMatch(Split(tt.pattern, "/"), Split(tt.path, "/")); got != tt.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreAnchored(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"build", "sub/build", "sub/out"} {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Patterns given before and after the path.
+	for _, m := range []*Model{
+		New(Ignore("/build", "sub/out"), Path(dir)),
+		New(Path(dir), Ignore("/build", "sub/out")),
+	} {
+		m.Init()
+		if got := fileNames(m); len(got) != 1 || got[0] != "sub" {
+			t.Errorf("listed %q, want only sub", got)
+		}
+		m.path = filepath.Join(dir, "sub")
+		m.list()
+		if got := fileNames(m); len(got) != 1 || got[0] != "build" {
+			t.Errorf("listed %q in sub, want only build", got)
+		}
+	}
+
+	fsys := fstest.MapFS{
+		"build/a":     {},
+		"sub/build/a": {},
+	}
+	m := New(FS(fsys), Ignore("/build"))
+	m.Init()
+	if got := fileNames(m); len(got) != 1 || got[0] != "sub" {
+		t.Errorf("listed %q in file system, want only sub", got)
+	}
+}
@@ -7,38 +7,59 @@ import (
 	_ "image/gif"
 	_ "image/jpeg"
 	_ "image/png"
-	"os"
-	"path/filepath"
+	"math"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/nfnt/resize"
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
 )
 
-func isImageExt(filePath string) bool {
-	ext := filepath.Ext(filePath)
-	return ext == ".png" || ext == ".jpg" || ext == ".jpeg" || ext == ".gif"
+// imageConfig returns the format and dimensions of the image file, as sniffed
+// from its content, or an error if it is not an image in a supported format.
+func (src *previewSource) imageConfig() (image.Config, string, error) {
+	file, err := src.open()
+	if err != nil {
+		return image.Config{}, "", err
+	}
+	defer file.Close()
+
+	return image.DecodeConfig(file)
 }
 
-func drawImage(path string, width, height int) (string, error) {
-	file, err := os.Open(path)
+// decodeImage decodes the image file.
+func (src *previewSource) decodeImage() (image.Image, error) {
+	file, err := src.open()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer file.Close()
 
 	img, _, err := image.Decode(file)
-	if err != nil {
-		return "", err
-	}
+	return img, err
+}
 
-	img = resize.Resize(uint(width), uint(height)*2, img, resize.Lanczos3)
+// drawImage draws img with half-block characters, two pixels per cell, in
+// at most width x height cells of cellWidth x cellHeight pixels, preserving
+// its aspect ratio.
+func drawImage(img image.Image, width, height, cellWidth, cellHeight int) string {
 	bounds := img.Bounds()
+	scale := math.Min(
+		float64(width*cellWidth)/float64(bounds.Dx()),
+		float64(height*cellHeight)/float64(bounds.Dy()),
+	)
+	columns := max(int(float64(bounds.Dx())*scale/float64(cellWidth)), 1)
+	halfRows := max(int(float64(bounds.Dy())*scale*2/float64(cellHeight)), 1)
+	img = resize.Resize(uint(columns), uint(halfRows), img, resize.Lanczos3)
+	bounds = img.Bounds()
 
 	var buffer bytes.Buffer
-	for y := bounds.Min.Y + 1; y < bounds.Max.Y-1; y += 2 {
-		for x := bounds.Min.X + 1; x < bounds.Max.X-1; x++ {
-			r1, g1, b1, a1 := img.At(x, y).RGBA()
-			r2, g2, b2, a2 := img.At(x, y+1).RGBA()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += 2 {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			// Lower half block is drawn in foreground color.
+			r1, g1, b1, a1 := img.At(x, y+1).RGBA()
+			r2, g2, b2, a2 := img.At(x, y).RGBA()
 
 			// If both pixels are transparent, print a space.
 			if a1 < 6553 && a2 < 6553 {
@@ -58,5 +79,5 @@ func drawImage(path string, width, height int) (string, error) {
 		}
 		buffer.WriteString("\n")
 	}
-	return buffer.String(), nil
+	return buffer.String()
 }
@@ -0,0 +1,475 @@
+package walk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	. "strings"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// jobOp identifies the file operation performed by a job.
+type jobOp int
+
+const (
+	jobCopy jobOp = iota
+	jobMove
+	jobExtract
+)
+
+func (op jobOp) String() string {
+	switch op {
+	case jobCopy:
+		return "copy"
+	case jobMove:
+		return "move"
+	case jobExtract:
+		return "extract"
+	}
+	return "unknown"
+}
+
+// conflictChoice is the resolution of a name conflict at a paste destination.
+type conflictChoice int
+
+const (
+	conflictOverwrite conflictChoice = iota
+	conflictSkip
+	conflictRename
+	conflictRenameAll
+)
+
+// job copies or moves files into a directory, or extracts an archive to it, in
+// the background.
+//
+// The progress and conflict fields are only accessed by the Bubble Tea runtime,
+// which updates them from the messages sent by the job's goroutine.
+type job struct {
+	id      int
+	op      jobOp
+	srcs    []string // Absolute paths of files to copy or move.
+	dst     string   // Absolute path of destination directory.
+	member  string   // File of archive srcs[0] extracted, "." for all.
+	ctx     context.Context
+	cancel  context.CancelFunc
+	events  chan tea.Msg
+	choices chan conflictChoice
+
+	progress jobProgressMsg // Last progress reported.
+	conflict string         // Destination path awaiting a conflict choice.
+}
+
+type (
+	jobProgressMsg struct {
+		id          int
+		done, total int64
+		name        string
+	}
+	jobConflictMsg struct {
+		id   int
+		path string
+	}
+	jobDoneMsg struct {
+		id   int
+		last string // Path of the last file copied or moved.
+		err  error
+	}
+)
+
+func newJob(id int, op jobOp, srcs []string, dst string) *job {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &job{
+		id:      id,
+		op:      op,
+		srcs:    srcs,
+		dst:     dst,
+		ctx:     ctx,
+		cancel:  cancel,
+		events:  make(chan tea.Msg),
+		choices: make(chan conflictChoice, 1),
+	}
+}
+
+// start runs the job in a new goroutine and returns a command that waits for
+// its first message.
+func (j *job) start() tea.Cmd {
+	go func() {
+		t := &transfer{job: j}
+		last, err := t.run()
+		j.events <- jobDoneMsg{id: j.id, last: last, err: err}
+	}()
+	return j.wait()
+}
+
+// queueJob queues j, and returns a command starting it if no other job is
+// running.
+func (m *Model) queueJob(j *job) tea.Cmd {
+	m.jobs = append(m.jobs, j)
+	if len(m.jobs) == 1 {
+		return j.start()
+	}
+	return nil
+}
+
+// moving reports whether a job queued or running moves any of the files at
+// paths.
+func (m *Model) moving(paths []string) bool {
+	for _, j := range m.jobs {
+		if j.op != jobMove {
+			continue
+		}
+		for _, src := range j.srcs {
+			for _, path := range paths {
+				if src == path {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// uncut removes the files moved from srcs from the register, once their move
+// job is done. Files left where they were cut from, e.g., as the job failed,
+// are kept to be pasted again.
+func (m *Model) uncut(srcs []string) {
+	if !m.registerCut {
+		return
+	}
+	moved := make(map[string]bool)
+	for _, src := range srcs {
+		if _, err := os.Lstat(src); err != nil {
+			moved[src] = true
+		}
+	}
+	var register []string
+	for _, path := range m.register {
+		if !moved[path] {
+			register = append(register, path)
+		}
+	}
+	m.register = register
+	m.registerCut = len(register) > 0
+}
+
+// quit returns the command quitting the program, unless a job is running. The
+// job is cancelled instead, and the program quits once it has cleaned up,
+// without starting the jobs queued.
+func (m *Model) quit() tea.Cmd {
+	if len(m.jobs) == 0 {
+		return m.exit()
+	}
+	m.quitting = true
+	m.jobs = m.jobs[:1]
+	m.jobs[0].cancel()
+	return nil
+}
+
+// wait returns a command that waits for the next message sent by the job.
+func (j *job) wait() tea.Cmd {
+	return func() tea.Msg { return <-j.events }
+}
+
+// resolve answers the pending conflict of the job with the given choice.
+func (j *job) resolve(choice conflictChoice) {
+	j.conflict = ""
+	j.choices <- choice
+}
+
+// jobView returns the progress line of the running job.
+func (m *Model) jobView(width int) string {
+	j := m.jobs[0]
+	if j.conflict != "" {
+		return m.st.Danger.Render(fmt.Sprintf(
+			"%v exists: (o)verwrite, (s)kip, (r)ename, rename (a)ll",
+			filepath.Base(j.conflict)))
+	}
+	var percent float64
+	if j.progress.total > 0 {
+		percent = float64(j.progress.done) / float64(j.progress.total)
+	}
+	label := fmt.Sprintf("%v %v ", j.op, filepath.Base(j.progress.name))
+	if m.quitting {
+		label = "quitting, cancelling " + label
+	}
+	if queued := len(m.jobs) - 1; queued > 0 {
+		label = fmt.Sprintf("%v(+%d queued) ", label, queued)
+	}
+	bar := m.progress
+	bar.Width = max(10, min(40, width-len(label)))
+	return m.st.Bar.Render(label) + " " + bar.ViewAs(percent)
+}
+
+// transfer is the state of a job's goroutine.
+type transfer struct {
+	*job
+	done, total int64
+	reported    time.Time
+	renameAll   bool
+}
+
+func (t *transfer) run() (last string, err error) {
+	if t.op == jobExtract {
+		return t.extract()
+	}
+	for _, src := range t.srcs {
+		t.total += diskUsage(src)
+	}
+	for _, src := range t.srcs {
+		if err := t.ctx.Err(); err != nil {
+			return last, err
+		}
+		if t.dst == src || HasPrefix(t.dst, src+fileSeparator) {
+			return last, fmt.Errorf("cannot %v %v into itself", t.op, filepath.Base(src))
+		}
+		dst := filepath.Join(t.dst, filepath.Base(src))
+		if dst == src && t.op == jobMove {
+			// Nothing to do.
+			t.done += diskUsage(src)
+			continue
+		}
+		if dst == src {
+			dst = uniquePath(dst)
+		} else if _, err := os.Lstat(dst); err == nil {
+			choice, err := t.resolve(dst)
+			if err != nil {
+				return last, err
+			}
+			switch choice {
+			case conflictSkip:
+				t.done += diskUsage(src)
+				continue
+			case conflictRename:
+				dst = uniquePath(dst)
+			case conflictOverwrite:
+				if err := t.overwrite(src, dst); err != nil {
+					return last, err
+				}
+				last = dst
+				continue
+			}
+		}
+		switch t.op {
+		case jobCopy:
+			err = t.copy(src, dst)
+		case jobMove:
+			err = t.move(src, dst)
+		}
+		if err != nil {
+			return last, err
+		}
+		last = dst
+	}
+	return last, nil
+}
+
+// overwrite copies or moves src to a temporary file next to dst, and replaces
+// dst with it once done, so that dst is kept if the job fails.
+func (t *transfer) overwrite(src, dst string) error {
+	if src == dst || HasPrefix(src, dst+fileSeparator) {
+		return fmt.Errorf("cannot overwrite %v with a file inside it", filepath.Base(dst))
+	}
+	tmp := newPath(filepath.Join(filepath.Dir(dst), "."+filepath.Base(dst)+".tmp"))
+	var err error
+	switch t.op {
+	case jobCopy:
+		err = t.copy(src, tmp)
+	case jobMove:
+		err = t.move(src, tmp)
+	}
+	if err != nil {
+		_ = os.RemoveAll(tmp)
+		return err
+	}
+	if err := os.RemoveAll(dst); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// resolve asks the user how to resolve a conflict at path dst. Once rename-all
+// was chosen, every following conflict of the job is renamed without asking.
+func (t *transfer) resolve(dst string) (conflictChoice, error) {
+	if t.renameAll {
+		return conflictRename, nil
+	}
+	t.events <- jobConflictMsg{id: t.id, path: dst}
+	select {
+	case choice := <-t.choices:
+		if choice == conflictRenameAll {
+			t.renameAll = true
+			choice = conflictRename
+		}
+		return choice, nil
+	case <-t.ctx.Done():
+		return conflictSkip, t.ctx.Err()
+	}
+}
+
+// report sends the progress of the job, at most every 100 milliseconds.
+func (t *transfer) report(name string) {
+	if t.events == nil || time.Since(t.reported) < 100*time.Millisecond {
+		return
+	}
+	t.reported = time.Now()
+	t.events <- jobProgressMsg{id: t.id, done: t.done, total: t.total, name: name}
+}
+
+// moveFile moves src to dst like a move job, without reporting progress.
+func moveFile(src, dst string) error {
+	t := &transfer{job: &job{op: jobMove, ctx: context.Background()}}
+	return t.move(src, dst)
+}
+
+// move renames src to dst, falling back to copy and delete if they reside on
+// different devices.
+func (t *transfer) move(src, dst string) error {
+	size := diskUsage(src)
+	err := os.Rename(src, dst)
+	if err == nil {
+		t.done += size
+		t.report(src)
+		return nil
+	}
+	if !isCrossDevice(err) {
+		return err
+	}
+	_, err = os.Lstat(dst)
+	existed := err == nil
+	if err := t.copy(src, dst); err != nil {
+		if !existed {
+			// Don't leave a partial copy behind, as src is kept.
+			_ = os.RemoveAll(dst)
+		}
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// copy copies the file or directory tree src to dst. Symbolic links are
+// copied as links, not followed.
+func (t *transfer) copy(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	switch mode := info.Mode(); {
+	case mode&fs.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+
+	case mode.IsDir():
+		if err := os.Mkdir(dst, mode.Perm()|0o700); err != nil {
+			return err
+		}
+		files, err := os.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			err := t.copy(filepath.Join(src, file.Name()), filepath.Join(dst, file.Name()))
+			if err != nil {
+				return err
+			}
+		}
+		return os.Chmod(dst, mode.Perm())
+
+	case mode.IsRegular():
+		if err := t.copyFile(src, dst, mode.Perm()); err != nil {
+			return err
+		}
+		return os.Chtimes(dst, info.ModTime(), info.ModTime())
+	}
+	return fmt.Errorf("%v: cannot %v irregular file", src, t.op)
+}
+
+func (t *transfer) copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	return t.writeFile(dst, in, perm, src)
+}
+
+// writeFile creates the file dst with the content read from in, reporting the
+// progress of copying name.
+func (t *transfer) writeFile(dst string, in io.Reader, perm fs.FileMode, name string) (err error) {
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			// Don't leave partial copies behind.
+			_ = os.Remove(dst)
+		}
+	}()
+	buf := make([]byte, 256*1024)
+	for {
+		if err := t.ctx.Err(); err != nil {
+			return err
+		}
+		n, rerr := in.Read(buf)
+		if n > 0 {
+			if _, err := out.Write(buf[:n]); err != nil {
+				return err
+			}
+			t.done += int64(n)
+			t.report(name)
+		}
+		if rerr == io.EOF {
+			return nil
+		}
+		if rerr != nil {
+			return rerr
+		}
+	}
+}
+
+// diskUsage returns the total size of regular files in the tree at path.
+func diskUsage(path string) int64 {
+	var size int64
+	_ = filepath.Walk(path, func(_ string, info fs.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// uniquePath returns a path in the same directory as path that does not exist
+// yet, made by appending a counter to its name, e.g., "file (1).txt".
+func uniquePath(path string) string {
+	ext := filepath.Ext(path)
+	if info, err := os.Lstat(path); err == nil && info.IsDir() {
+		ext = ""
+	}
+	stem := TrimSuffix(path, ext)
+	for i := 1; ; i++ {
+		name := fmt.Sprintf("%s (%d)%s", stem, i, ext)
+		if _, err := os.Lstat(name); errors.Is(err, fs.ErrNotExist) {
+			return name
+		}
+	}
+}
+
+// isCrossDevice reports whether err is caused by renaming a file across
+// different file systems.
+func isCrossDevice(err error) bool {
+	return errors.Is(err, syscall.EXDEV)
+}
@@ -0,0 +1,164 @@
+package walk
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// runJob runs j to completion, answering conflicts with choice.
+func runJob(t *testing.T, j *job, choice conflictChoice) jobDoneMsg {
+	t.Helper()
+	j.start()
+	for {
+		switch msg := (<-j.events).(type) {
+		case jobConflictMsg:
+			j.resolve(choice)
+		case jobDoneMsg:
+			return msg
+		}
+	}
+}
+
+func TestOverwriteAncestor(t *testing.T) {
+	for _, op := range []jobOp{jobCopy, jobMove} {
+		t.Run(op.String(), func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, "foo", "foo")
+			if err := os.MkdirAll(src, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(src, "data"), []byte("data"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			msg := runJob(t, newJob(1, op, []string{src}, dir), conflictOverwrite)
+			if msg.err == nil {
+				t.Fatal("overwriting a directory with a file inside it succeeded")
+			}
+			if _, err := os.Stat(filepath.Join(src, "data")); err != nil {
+				t.Fatalf("source lost: %v", err)
+			}
+		})
+	}
+}
+
+func TestOverwrite(t *testing.T) {
+	for _, op := range []jobOp{jobCopy, jobMove} {
+		t.Run(op.String(), func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, "src", "file")
+			dst := filepath.Join(dir, "file")
+			if err := os.Mkdir(filepath.Dir(src), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(src, []byte("new"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(dst, []byte("old"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			msg := runJob(t, newJob(1, op, []string{src}, dir), conflictOverwrite)
+			if msg.err != nil {
+				t.Fatal(msg.err)
+			}
+			if msg.last != dst {
+				t.Errorf("last = %q, want %q", msg.last, dst)
+			}
+			if content, err := os.ReadFile(dst); err != nil || string(content) != "new" {
+				t.Errorf("content = %q, %v, want %q", content, err, "new")
+			}
+			if _, err := os.Stat(src); (err == nil) != (op == jobCopy) {
+				t.Errorf("source exists = %v after %v", err == nil, op)
+			}
+			files, _ := os.ReadDir(dir)
+			if len(files) != 2 {
+				t.Errorf("%d files in destination, want 2", len(files))
+			}
+		})
+	}
+}
+
+func TestQuitCancelsJob(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src", "file")
+	if err := os.Mkdir(filepath.Dir(src), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{src, filepath.Join(dir, "file")} {
+		if err := os.WriteFile(path, []byte("data"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := New(Path(dir), Size(80, 24))
+	m.Init()
+	cmd := m.queueJob(newJob(1, jobCopy, []string{src}, dir))
+	m.queueJob(newJob(2, jobCopy, []string{src}, dir))
+	_, cmd = m.Update(cmd()) // Waiting for a conflict choice.
+
+	if _, quit := m.Update(tea.KeyMsg{Type: tea.KeyCtrlC}); quit != nil {
+		t.Fatal("quit while a job is running")
+	}
+	msg, ok := cmd().(jobDoneMsg)
+	if !ok || !errors.Is(msg.err, context.Canceled) {
+		t.Fatalf("job not cancelled: %#v", msg)
+	}
+	_, quit := m.Update(msg)
+	if quit == nil {
+		t.Fatal("not quit once the job is cancelled")
+	}
+	if _, ok := quit().(tea.QuitMsg); !ok || len(m.jobs) > 0 {
+		t.Errorf("quit = %T, %d jobs left", quit(), len(m.jobs))
+	}
+}
+
+func TestPasteCut(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src", "file")
+	if err := os.Mkdir(filepath.Dir(src), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(src, []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := New(Path(dir), Size(80, 24))
+	m.Init()
+	m.register, m.registerCut = []string{src}, true
+
+	_, cmd := m.Update(press("P"))
+	if len(m.register) == 0 {
+		t.Fatal("register cleared before files are moved")
+	}
+	if _, again := m.Update(press("P")); again != nil || len(m.jobs) != 1 {
+		t.Fatalf("files moved again, %d jobs", len(m.jobs))
+	}
+	for cmd != nil {
+		_, cmd = m.Update(cmd())
+	}
+	if len(m.register) > 0 || m.registerCut {
+		t.Errorf("register = %v, cut = %v once moved", m.register, m.registerCut)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "file")); err != nil {
+		t.Error(err)
+	}
+
+	// Files not moved are kept cut.
+	sub := filepath.Join(dir, "src", "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	m = New(Path(sub), Size(80, 24))
+	m.Init()
+	m.register, m.registerCut = []string{filepath.Dir(sub)}, true
+	for _, cmd = m.Update(press("P")); cmd != nil; {
+		_, cmd = m.Update(cmd())
+	}
+	if m.opErr == nil || len(m.register) != 1 || !m.registerCut {
+		t.Errorf("error = %v, register = %v, cut = %v", m.opErr, m.register, m.registerCut)
+	}
+}
@@ -6,39 +6,67 @@ import "github.com/charmbracelet/bubbles/key"
 //
 // The type itself is unexported to prevent accidentally using a zero-valued
 // keyMap with uninitialized bindings. Users can construct and modify a fully
-// initialized keyMap returned from NewKeyMap. 
+// initialized keyMap returned from NewKeyMap.
 type keyMap struct {
-	ForceQuit key.Binding
-	Quit      key.Binding
-	QuitQ     key.Binding
-	Submit    key.Binding
-	Next      key.Binding
-	Prev      key.Binding
-	Open      key.Binding
-	Back      key.Binding
-	Up        key.Binding
-	Down      key.Binding
-	Left      key.Binding
-	Right     key.Binding
-	Top       key.Binding
-	Bottom    key.Binding
-	Leftmost  key.Binding
-	Rightmost key.Binding
-	PageUp    key.Binding
-	PageDown  key.Binding
-	Home      key.Binding
-	End       key.Binding
-	VimUp     key.Binding
-	VimDown   key.Binding
-	VimLeft   key.Binding
-	VimRight  key.Binding
-	VimTop    key.Binding
-	VimBottom key.Binding
-	Search    key.Binding
-	Preview   key.Binding
-	Delete    key.Binding
-	Undo      key.Binding
-	Yank      key.Binding
+	ForceQuit   key.Binding
+	Quit        key.Binding
+	QuitQ       key.Binding
+	Submit      key.Binding
+	Next        key.Binding
+	Prev        key.Binding
+	Open        key.Binding
+	Back        key.Binding
+	Up          key.Binding
+	Down        key.Binding
+	Left        key.Binding
+	Right       key.Binding
+	Top         key.Binding
+	Bottom      key.Binding
+	Leftmost    key.Binding
+	Rightmost   key.Binding
+	PageUp      key.Binding
+	PageDown    key.Binding
+	Home        key.Binding
+	End         key.Binding
+	VimUp       key.Binding
+	VimDown     key.Binding
+	VimLeft     key.Binding
+	VimRight    key.Binding
+	VimTop      key.Binding
+	VimBottom   key.Binding
+	Search      key.Binding
+	Preview     key.Binding
+	Delete      key.Binding
+	Undo        key.Binding
+	Yank        key.Binding
+	Sort        key.Binding
+	SortReverse key.Binding
+	Hidden      key.Binding
+	ClearSearch key.Binding
+	NextMatch   key.Binding
+	PrevMatch   key.Binding
+	Mark        key.Binding
+	Visual      key.Binding
+	MarkAll     key.Binding
+	InvertMarks key.Binding
+	ClearMarks  key.Binding
+	Copy        key.Binding
+	Cut         key.Binding
+	Paste       key.Binding
+	CancelJob   key.Binding
+	Trash       key.Binding
+	Restore     key.Binding
+	Rename      key.Binding
+	BulkRename  key.Binding
+	NewFile     key.Binding
+	NewDir      key.Binding
+	NewSymlink  key.Binding
+	NewHardLink key.Binding
+	Long        key.Binding
+	Raw         key.Binding
+	Focus       key.Binding
+	Extract     key.Binding
+	JumpLink    key.Binding
 }
 
 func NewKeyMap() *keyMap { return new(keyMap).Default() }
@@ -78,5 +106,33 @@ func (k *keyMap) Default() *keyMap {
 	k.Delete = key.NewBinding(key.WithKeys("delete", "d"))
 	k.Undo = key.NewBinding(key.WithKeys("u", "z"))
 	k.Yank = key.NewBinding(key.WithKeys("y"))
+	k.Sort = key.NewBinding(key.WithKeys("s"))
+	k.SortReverse = key.NewBinding(key.WithKeys("S"))
+	k.Hidden = key.NewBinding(key.WithKeys("."))
+	k.ClearSearch = key.NewBinding(key.WithKeys("ctrl+u"))
+	k.NextMatch = key.NewBinding(key.WithKeys("ctrl+n"))
+	k.PrevMatch = key.NewBinding(key.WithKeys("ctrl+p"))
+	k.Mark = key.NewBinding(key.WithKeys("m"))
+	k.Visual = key.NewBinding(key.WithKeys("v"))
+	k.MarkAll = key.NewBinding(key.WithKeys("ctrl+a"))
+	k.InvertMarks = key.NewBinding(key.WithKeys("*"))
+	k.ClearMarks = key.NewBinding(key.WithKeys("M"))
+	k.Copy = key.NewBinding(key.WithKeys("c"))
+	k.Cut = key.NewBinding(key.WithKeys("x"))
+	k.Paste = key.NewBinding(key.WithKeys("P"))
+	k.CancelJob = key.NewBinding(key.WithKeys("ctrl+x"))
+	k.Trash = key.NewBinding(key.WithKeys("T"))
+	k.Restore = key.NewBinding(key.WithKeys("r"))
+	k.Rename = key.NewBinding(key.WithKeys("r"))
+	k.BulkRename = key.NewBinding(key.WithKeys("R"))
+	k.NewFile = key.NewBinding(key.WithKeys("a"))
+	k.NewDir = key.NewBinding(key.WithKeys("A"))
+	k.NewSymlink = key.NewBinding(key.WithKeys("L"))
+	k.NewHardLink = key.NewBinding(key.WithKeys("H"))
+	k.Long = key.NewBinding(key.WithKeys("i"))
+	k.Raw = key.NewBinding(key.WithKeys("t"))
+	k.Focus = key.NewBinding(key.WithKeys("f"))
+	k.Extract = key.NewBinding(key.WithKeys("X"))
+	k.JumpLink = key.NewBinding(key.WithKeys("J"))
 	return k
 }
@@ -0,0 +1,159 @@
+package walk
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+)
+
+// maxLinks is the maximum number of symbolic links followed in a row, as in
+// Linux.
+const maxLinks = 40
+
+// isLink reports whether file is a symbolic link.
+func isLink(file fs.DirEntry) bool {
+	return file.Type()&fs.ModeSymlink != 0
+}
+
+// linkTarget returns the path of the file the symbolic link at p finally
+// points to, which may not exist.
+func (m *Model) linkTarget(p string) (string, error) {
+	if fsys, _ := m.fsName(p); fsys == nil {
+		if target, err := filepath.EvalSymlinks(p); err == nil {
+			return target, nil
+		}
+	}
+	for i := 0; i < maxLinks; i++ {
+		info, err := m.lstat(p)
+		if errors.Is(err, fs.ErrNotExist) && i > 0 {
+			return p, nil // Broken link.
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&fs.ModeSymlink == 0 {
+			return p, nil
+		}
+		target, err := m.readLink(p)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case m.fsys != nil && path.IsAbs(target):
+			return "", fmt.Errorf("%v points outside of file system", path.Base(p))
+		case m.fsys != nil:
+			p = path.Join(path.Dir(p), target)
+		case filepath.IsAbs(target):
+			p = filepath.Clean(target)
+		default:
+			p = filepath.Join(filepath.Dir(p), target)
+		}
+	}
+	return "", fmt.Errorf("%v: too many levels of symbolic links", path.Base(p))
+}
+
+// linkInfo describes a symbolic link as listed.
+type linkInfo struct {
+	suffix string // Target listed after the name of the link.
+	broken bool   // Whether the target does not exist.
+}
+
+// readLinkInfo returns the description of file, a symbolic link in directory
+// dir. Its suffix is, e.g., " -> target/" if a directory, or with " (broken)"
+// appended if it does not exist.
+func (m *Model) readLinkInfo(dir string, file fs.DirEntry) linkInfo {
+	filePath := m.join(dir, file.Name())
+	target, err := m.readLink(filePath)
+	if err != nil {
+		_, err := m.stat(filePath)
+		return linkInfo{suffix: " -> ?", broken: err != nil}
+	}
+	info, err := m.stat(filePath)
+	switch {
+	case err != nil:
+		return linkInfo{suffix: " -> " + target + " (broken)", broken: true}
+	case info.IsDir():
+		return linkInfo{suffix: " -> " + target + fileSeparator}
+	}
+	return linkInfo{suffix: " -> " + target}
+}
+
+// linkFiles reads the symbolic links in the current directory once listed,
+// so that laying out the listing does not read them again.
+func (m *Model) linkFiles() {
+	m.links = nil
+	for _, file := range m.listing {
+		if !isLink(file) {
+			continue
+		}
+		if m.links == nil {
+			m.links = make(map[string]linkInfo)
+		}
+		m.links[file.Name()] = m.readLinkInfo(m.path, file)
+	}
+}
+
+// link returns the description of file, if a symbolic link in directory dir.
+func (m *Model) link(dir string, file fs.DirEntry) (linkInfo, bool) {
+	if !isLink(file) {
+		return linkInfo{}, false
+	}
+	if l, ok := m.links[file.Name()]; ok && dir == m.path {
+		return l, true
+	}
+	return m.readLinkInfo(dir, file), true
+}
+
+// linkSuffix returns the target of file, if a symbolic link in directory dir,
+// as listed after its name.
+func (m *Model) linkSuffix(dir string, file fs.DirEntry) string {
+	l, _ := m.link(dir, file)
+	return l.suffix
+}
+
+// isBrokenLink reports whether file is a symbolic link in directory dir to a
+// file that does not exist.
+func (m *Model) isBrokenLink(dir string, file fs.DirEntry) bool {
+	l, _ := m.link(dir, file)
+	return l.broken
+}
+
+// jumpToTarget moves to the directory containing the file that the symbolic
+// link under the cursor points to, and places the cursor on it.
+func (m *Model) jumpToTarget() error {
+	filePath, ok := m.filePath()
+	if !ok {
+		return nil
+	}
+	if info, err := m.lstat(filePath); err != nil {
+		return err
+	} else if info.Mode()&fs.ModeSymlink == 0 {
+		return fmt.Errorf("%v is not a symbolic link", info.Name())
+	}
+	target, err := m.linkTarget(filePath)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(target)
+	if m.fsys != nil {
+		dir = path.Dir(target)
+	}
+	if info, err := m.stat(dir); err != nil {
+		return err
+	} else if !info.IsDir() {
+		return fmt.Errorf("%v is not a directory", dir)
+	}
+	m.commitVisual()
+	m.saveCursorPosition()
+	m.path = dir
+	m.leaveArchive()
+	m.c, m.r, m.offset = 0, 0, 0
+	m.list()
+	m.selectPath(target)
+	if _, err := m.lstat(target); err != nil {
+		m.notice = fmt.Sprintf("%v does not exist", filepath.Base(target))
+	}
+	return nil
+}
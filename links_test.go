@@ -0,0 +1,47 @@
+package walk
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+// readLinkCountFS is a fstest.MapFS counting the symbolic links read.
+type readLinkCountFS struct {
+	fstest.MapFS
+	calls *int
+}
+
+func (f readLinkCountFS) ReadLink(name string) (string, error) {
+	*f.calls++
+	return f.MapFS.ReadLink(name)
+}
+
+func TestLinksReadOnce(t *testing.T) {
+	var calls int
+	fsys := readLinkCountFS{testFS(), &calls}
+	fsys.MapFS["broken"] = &fstest.MapFile{Data: []byte("missing"), Mode: testFS()["link"].Mode}
+	m := New(FS(fsys), Size(80, 24))
+	m.Init()
+	listed := calls
+
+	for i := 0; i < 3; i++ {
+		m.View()
+		send(m, press("j"))
+	}
+	if calls != listed {
+		t.Errorf("links read %d times when rendered", calls-listed)
+	}
+	for _, file := range m.files {
+		suffix, broken := m.linkSuffix(m.path, file), m.isBrokenLink(m.path, file)
+		switch file.Name() {
+		case "link":
+			if suffix != " -> dir/" || broken {
+				t.Errorf("link: suffix = %q, broken = %v", suffix, broken)
+			}
+		case "broken":
+			if suffix != " -> missing (broken)" || !broken {
+				t.Errorf("broken: suffix = %q, broken = %v", suffix, broken)
+			}
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package walk
+
+import (
+	"fmt"
+	"io/fs"
+	"math"
+	. "strings"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Column identifies a file metadata column shown in long listing mode.
+type Column int
+
+// Columns shown in long listing mode. The name of each file is always shown
+// first, followed by the selected columns.
+const (
+	ColumnMode   Column = iota // Permissions, as shown by ls -l.
+	ColumnUser                 // Name of owner.
+	ColumnGroup                // Name of owning group.
+	ColumnSize                 // Human-readable size.
+	ColumnTime                 // Modification time.
+	ColumnTarget               // Target of symbolic links.
+	columnCount
+)
+
+// DefaultColumns are the columns shown in long listing mode if none are
+// selected.
+var DefaultColumns = []Column{
+	ColumnMode, ColumnUser, ColumnGroup, ColumnSize, ColumnTime, ColumnTarget,
+}
+
+// longSeparator is the separator between columns in long listing mode.
+const longSeparator = "  "
+
+func (c Column) String() string {
+	switch c {
+	case ColumnMode:
+		return "mode"
+	case ColumnUser:
+		return "user"
+	case ColumnGroup:
+		return "group"
+	case ColumnSize:
+		return "size"
+	case ColumnTime:
+		return "time"
+	case ColumnTarget:
+		return "target"
+	}
+	return "unknown"
+}
+
+// ParseColumns parses a comma-separated list of column names, e.g.,
+// "mode,size,time". An empty list selects DefaultColumns.
+func ParseColumns(s string) ([]Column, error) {
+	if TrimSpace(s) == "" {
+		return DefaultColumns, nil
+	}
+	var columns []Column
+names:
+	for _, name := range Split(s, ",") {
+		name = TrimSpace(name)
+		for c := Column(0); c < columnCount; c++ {
+			if EqualFold(name, c.String()) {
+				columns = append(columns, c)
+				continue names
+			}
+		}
+		return nil, fmt.Errorf("unknown column: %q", name)
+	}
+	return columns, nil
+}
+
+// dropOrder lists the columns dropped first when the listing is too wide.
+var dropOrder = []Column{
+	ColumnGroup, ColumnUser, ColumnTarget, ColumnMode, ColumnTime, ColumnSize,
+}
+
+// longWrap formats files in a single column of rows with the file name and
+// selected metadata columns, in the same shape as returned by wrap. Columns
+// are dropped in dropOrder until the rows fit in width, then names are
+// truncated.
+func (m *Model) longWrap(files []fs.DirEntry, width int, callback func(name string, i, j int)) ([][]string, int, int) {
+	columns := m.longColumns
+	if len(columns) == 0 {
+		columns = DefaultColumns
+	}
+	now := time.Now()
+	cells := make([][]string, len(files))
+	for j, file := range files {
+		cells[j] = make([]string, 1+len(columns))
+		cells[j][0] = m.displayName(m.path, file)
+		if callback != nil {
+			callback(file.Name(), 0, j)
+		}
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		for k, column := range columns {
+			cells[j][k+1] = m.columnValue(column, info, file, m.path, now)
+		}
+	}
+
+	widths := make([]int, 1+len(columns))
+	for _, row := range cells {
+		for k, cell := range row {
+			widths[k] = max(widths[k], runewidth.StringWidth(cell))
+		}
+	}
+	// Drop columns until everything fits.
+	shown := make([]bool, 1+len(columns))
+	for k := range shown {
+		shown[k] = widths[k] > 0 || k == 0
+	}
+	total := func() int {
+		sum := 0
+		for k, w := range widths {
+			if shown[k] {
+				sum += w + len(longSeparator)
+			}
+		}
+		return sum - len(longSeparator)
+	}
+	for _, drop := range dropOrder {
+		if total() <= width {
+			break
+		}
+		for k, column := range columns {
+			if column == drop {
+				shown[k+1] = false
+			}
+		}
+	}
+	if over := total() - width; over > 0 && width > 0 {
+		widths[0] = max(widths[0]-over, 1)
+	}
+
+	names := [][]string{make([]string, len(files))}
+	for j, row := range cells {
+		var line []string
+		for k, cell := range row {
+			if k == 0 {
+				cell = truncateMiddle(cell, widths[0])
+			}
+			padding := Repeat(" ", widths[k]-runewidth.StringWidth(cell))
+			switch {
+			case !shown[k]:
+			case k > 0 && columns[k-1] == ColumnSize:
+				line = append(line, padding+cell) // Align sizes right.
+			default:
+				line = append(line, cell+padding)
+			}
+		}
+		names[0][j] = Join(line, longSeparator)
+	}
+	return names, len(files), 1
+}
+
+// columnValue formats the given metadata column of a file in directory dir.
+func (m *Model) columnValue(column Column, info fs.FileInfo, file fs.DirEntry, dir string, now time.Time) string {
+	switch column {
+	case ColumnMode:
+		return info.Mode().String()
+	case ColumnUser:
+		user, _ := fileOwner(info)
+		return user
+	case ColumnGroup:
+		_, group := fileOwner(info)
+		return group
+	case ColumnSize:
+		if info.IsDir() {
+			return "-"
+		}
+		return humanSize(info.Size())
+	case ColumnTime:
+		// Same as ls: show year instead of time for files older than 6 months.
+		if t := info.ModTime(); now.Sub(t) < 182*24*time.Hour && t.Before(now.Add(time.Hour)) {
+			return t.Format("Jan _2 15:04")
+		}
+		return info.ModTime().Format("Jan _2  2006")
+	case ColumnTarget:
+		if info.Mode()&fs.ModeSymlink == 0 {
+			return ""
+		}
+		return TrimPrefix(m.linkSuffix(dir, file), " ")
+	}
+	return ""
+}
+
+// humanSize formats size using binary unit prefixes, e.g., "1.5K".
+func humanSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	value := float64(size) / float64(div)
+	// Move up to the next unit rather than show "1024K".
+	if math.Round(value) >= unit && exp < len("KMGTPE")-1 {
+		value /= unit
+		exp++
+	}
+	// Below 9.95, one decimal doesn't round up to "10.0".
+	if value < 9.95 {
+		return fmt.Sprintf("%.1f%c", value, "KMGTPE"[exp])
+	}
+	return fmt.Sprintf("%.0f%c", value, "KMGTPE"[exp])
+}
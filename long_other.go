@@ -0,0 +1,11 @@
+//go:build windows || plan9
+
+package walk
+
+import "io/fs"
+
+// fileOwner returns empty names, as file ownership is not available from
+// fs.FileInfo on this platform.
+func fileOwner(info fs.FileInfo) (user, group string) {
+	return "", ""
+}
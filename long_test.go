@@ -0,0 +1,56 @@
+package walk
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseColumns(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []Column
+		err  bool
+	}{
+		{"", DefaultColumns, false},
+		{"  ", DefaultColumns, false},
+		{"size", []Column{ColumnSize}, false},
+		{"mode,size,time", []Column{ColumnMode, ColumnSize, ColumnTime}, false},
+		{" Target , USER", []Column{ColumnTarget, ColumnUser}, false},
+		{"size,size", []Column{ColumnSize, ColumnSize}, false},
+		{"size,owner", nil, true},
+		{"size,", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseColumns(tt.s)
+		if (err != nil) != tt.err || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseColumns(%q) = %v, %v, want %v", tt.s, got, err, tt.want)
+		}
+	}
+}
+
+func TestHumanSize(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{0, "0B"},
+		{1023, "1023B"},
+		{1024, "1.0K"},
+		{1536, "1.5K"},
+		{10188, "9.9K"},
+		{10189, "10K"},
+		{10240, "10K"},
+		{1048063, "1023K"},
+		{1048064, "1.0M"},
+		{1048575, "1.0M"},
+		{1 << 20, "1.0M"},
+		{5 << 30, "5.0G"},
+		{1<<40 - 1, "1.0T"},
+		{1<<63 - 1, "8.0E"},
+	}
+	for _, tt := range tests {
+		if got := humanSize(tt.size); got != tt.want {
+			t.Errorf("humanSize(%d) = %q, want %q", tt.size, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+//go:build !windows && !plan9
+
+package walk
+
+import (
+	"io/fs"
+	"os/user"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+// ownerNames caches user and group names by their numeric ids.
+var ownerNames sync.Map
+
+// fileOwner returns the names of the user and group owning a file, or their
+// numeric ids if the names cannot be looked up.
+func fileOwner(info fs.FileInfo) (user, group string) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", ""
+	}
+	return lookupOwner("u", st.Uid), lookupOwner("g", st.Gid)
+}
+
+func lookupOwner(kind string, id uint32) string {
+	key := kind + strconv.FormatUint(uint64(id), 10)
+	if name, ok := ownerNames.Load(key); ok {
+		return name.(string)
+	}
+	name := strconv.FormatUint(uint64(id), 10)
+	switch kind {
+	case "u":
+		if u, err := user.LookupId(name); err == nil {
+			name = u.Username
+		}
+	case "g":
+		if g, err := user.LookupGroupId(name); err == nil {
+			name = g.Name
+		}
+	}
+	ownerNames.Store(key, name)
+	return name
+}
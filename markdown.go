@@ -0,0 +1,41 @@
+package walk
+
+import (
+	"path/filepath"
+	. "strings"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// isMarkdownExt reports whether the file named fileName is a Markdown file.
+func isMarkdownExt(fileName string) bool {
+	switch ToLower(filepath.Ext(fileName)) {
+	case ".md", ".markdown", ".mdown", ".mkd", ".mkdn":
+		return true
+	}
+	return false
+}
+
+// renderMarkdown returns the lines of content, a Markdown document sanitized
+// for the terminal, rendered and word wrapped to width.
+func renderMarkdown(content string, width int) ([]string, error) {
+	style := "dark"
+	if lipgloss.ColorProfile() == termenv.Ascii {
+		style = "notty"
+	}
+	renderer, err := glamour.NewTermRenderer(
+		glamour.WithStandardStyle(style),
+		glamour.WithColorProfile(lipgloss.ColorProfile()),
+		glamour.WithWordWrap(width),
+	)
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := renderer.Render(content)
+	if err != nil {
+		return nil, err
+	}
+	return Split(Trim(rendered, "\n"), "\n"), nil
+}
@@ -0,0 +1,196 @@
+package walk
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	. "strings"
+	"time"
+)
+
+// PathListFS is a read-only file system of a list of paths of the operating
+// system, such as output by "find" or "git ls-files", arranged as a tree
+// below the deepest directory containing them all. Directories only contain
+// the paths listed, while files are read from the operating system.
+type PathListFS struct {
+	root string              // Directory containing all paths.
+	dirs map[string][]string // Base names of files listed in each directory.
+}
+
+// ReadPathList returns the file system of the paths read from r, separated
+// by NUL bytes if any, else by newlines. Relative paths are resolved against
+// the current directory.
+func ReadPathList(r io.Reader) (*PathListFS, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	sep := byte('\n')
+	if bytes.IndexByte(content, 0) >= 0 {
+		sep = 0
+	}
+	var paths []string
+	for _, line := range bytes.Split(content, []byte{sep}) {
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		if len(line) == 0 {
+			continue
+		}
+		p, err := filepath.Abs(string(line))
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, p)
+	}
+	if len(paths) == 0 {
+		return nil, errors.New("no paths listed")
+	}
+	return NewPathListFS(paths), nil
+}
+
+// NewPathListFS returns the file system of the given absolute paths. A file
+// or directory listed alone is listed in its parent directory.
+func NewPathListFS(paths []string) *PathListFS {
+	root, single := paths[0], true
+	for _, p := range paths {
+		single = single && p == root
+		for p != root && !HasPrefix(p, TrimSuffix(root, fileSeparator)+fileSeparator) {
+			root = filepath.Dir(root)
+		}
+	}
+	if single {
+		root = filepath.Dir(root) // Only one file or directory listed.
+	}
+	f := &PathListFS{root: root, dirs: map[string][]string{".": nil}}
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		rel, err := filepath.Rel(root, p)
+		if err != nil || rel == "." {
+			continue
+		}
+		// Add the file and any of its parents not listed yet.
+		for name := filepath.ToSlash(rel); !seen[name]; name = path.Dir(name) {
+			seen[name] = true
+			dir := path.Dir(name)
+			f.dirs[dir] = append(f.dirs[dir], path.Base(name))
+			if dir == "." {
+				break
+			}
+		}
+	}
+	for _, names := range f.dirs {
+		sort.Strings(names)
+	}
+	return f
+}
+
+// OSPath returns the path on the operating system of the file name.
+func (f *PathListFS) OSPath(name string) string {
+	return filepath.Join(f.root, filepath.FromSlash(name))
+}
+
+// Open opens the file name, or a directory listing only the files in it that
+// are in the path list.
+func (f *PathListFS) Open(name string) (fs.File, error) {
+	info, err := f.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return &pathListDir{fsys: f, name: name, info: info}, nil
+	}
+	return os.Open(f.OSPath(name))
+}
+
+// Stat returns information about the file name, following symbolic links.
+func (f *PathListFS) Stat(name string) (fs.FileInfo, error) {
+	info, err := f.Lstat(name)
+	if err != nil || info.Mode()&fs.ModeSymlink == 0 {
+		return info, err
+	}
+	return os.Stat(f.OSPath(name))
+}
+
+// ReadLink returns the target of the symbolic link name.
+func (f *PathListFS) ReadLink(name string) (string, error) {
+	if _, err := f.Lstat(name); err != nil {
+		return "", err
+	}
+	return os.Readlink(f.OSPath(name))
+}
+
+// Lstat returns information about the file name, not following symbolic
+// links. It is a directory if files in it are listed, even if it no longer
+// exists.
+func (f *PathListFS) Lstat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	_, isDir := f.dirs[name]
+	if !isDir && !f.listed(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	info, err := os.Lstat(f.OSPath(name))
+	if isDir && (err != nil || !info.IsDir()) {
+		return pathListDirInfo(path.Base(name)), nil
+	}
+	return info, err
+}
+
+// listed reports whether the file name is in the path list.
+func (f *PathListFS) listed(name string) bool {
+	names := f.dirs[path.Dir(name)]
+	i := sort.SearchStrings(names, path.Base(name))
+	return i < len(names) && names[i] == path.Base(name)
+}
+
+// pathListDir is a directory opened in a PathListFS.
+type pathListDir struct {
+	fsys   *PathListFS
+	name   string
+	info   fs.FileInfo
+	offset int // Number of entries read.
+}
+
+func (d *pathListDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *pathListDir) Close() error               { return nil }
+func (d *pathListDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errors.New("is a directory")}
+}
+
+// ReadDir reads the next n entries of the directory, or all if n <= 0.
+// Listed files that no longer exist are skipped.
+func (d *pathListDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.fsys.dirs[d.name][d.offset:]
+	if n > 0 && len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > 0 && len(rest) > n {
+		rest = rest[:n]
+	}
+	d.offset += len(rest)
+	entries := make([]fs.DirEntry, 0, len(rest))
+	for _, base := range rest {
+		info, err := d.fsys.Lstat(path.Join(d.name, base))
+		if err != nil {
+			continue
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+	return entries, nil
+}
+
+// pathListDirInfo describes a directory of a PathListFS that is not a
+// directory of the operating system.
+type pathListDirInfo string
+
+func (i pathListDirInfo) Name() string       { return string(i) }
+func (i pathListDirInfo) Size() int64        { return 0 }
+func (i pathListDirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0o555 }
+func (i pathListDirInfo) ModTime() time.Time { return time.Time{} }
+func (i pathListDirInfo) IsDir() bool        { return true }
+func (i pathListDirInfo) Sys() any           { return nil }
@@ -0,0 +1,77 @@
+package walk
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	. "strings"
+	"testing"
+)
+
+func TestReadPathList(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "c.txt", "sub/b.txt"} {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name  string
+		list  []string // Paths relative to dir.
+		sep   string
+		root  string // Root relative to dir.
+		names map[string]string
+	}{
+		{"newlines", []string{"a.txt", "sub/b.txt"}, "\n", ".",
+			map[string]string{".": "a.txt sub", "sub": "b.txt"}},
+		{"NUL bytes", []string{"a.txt", "c.txt"}, "\x00", ".",
+			map[string]string{".": "a.txt c.txt"}},
+		{"CRLF", []string{"a.txt", "c.txt"}, "\r\n", ".",
+			map[string]string{".": "a.txt c.txt"}},
+		{"duplicates", []string{"sub/b.txt", "a.txt", "sub/b.txt", "a.txt"}, "\n", ".",
+			map[string]string{".": "a.txt sub", "sub": "b.txt"}},
+		{"common root", []string{"sub/b.txt", "a.txt"}, "\n", ".",
+			map[string]string{".": "a.txt sub"}},
+		{"listed root", []string{"sub", "sub/b.txt"}, "\n", "sub",
+			map[string]string{".": "b.txt"}},
+		{"single file", []string{"sub/b.txt"}, "\n", "sub",
+			map[string]string{".": "b.txt"}},
+		{"single directory", []string{"sub"}, "\n", ".",
+			map[string]string{".": "sub"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input Builder
+			for _, p := range tt.list {
+				input.WriteString(filepath.Join(dir, filepath.FromSlash(p)) + tt.sep)
+			}
+			f, err := ReadPathList(NewReader(input.String()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if root := filepath.Join(dir, tt.root); f.OSPath(".") != root {
+				t.Errorf("root = %q, want %q", f.OSPath("."), root)
+			}
+			for name, want := range tt.names {
+				entries, err := fs.ReadDir(f, name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var names []string
+				for _, entry := range entries {
+					names = append(names, entry.Name())
+				}
+				if got := Join(names, " "); got != want {
+					t.Errorf("%s lists %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+	if _, err := ReadPathList(NewReader("\n\n")); err == nil {
+		t.Error("no error reading an empty list")
+	}
+}
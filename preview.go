@@ -0,0 +1,768 @@
+package walk
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"regexp"
+	. "strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alecthomas/chroma"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// previewChunkSize is the number of bytes of a file read at a time to
+// preview it. More chunks are read as the preview is scrolled.
+const previewChunkSize = 64 * 1024
+
+// previewLoadChunks is the number of chunks loaded at a time in the
+// background, e.g., to scroll a preview to its end.
+const previewLoadChunks = 16
+
+// maxMarkdownSize is the size of the largest Markdown file rendered in the
+// preview; larger files are shown as text.
+const maxMarkdownSize = 1024 * 1024
+
+// ansiEscape matches the terminal escape sequences of styled text.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+// previewKey identifies the content of the preview pane. The preview is
+// generated again only if it changes.
+type previewKey struct {
+	path          string
+	modTime       time.Time
+	size          int64
+	width, height int
+	raw           bool     // Whether Markdown is shown as source.
+	hidden        bool     // Whether directory listing shows hidden files.
+	sortMode      SortMode // Sort order of directory listing.
+	sortReverse   bool
+	archive       *archive // Archive containing the file, if any.
+}
+
+// previewBuffer is the content of the preview pane, loaded lazily as it is
+// scrolled.
+type previewBuffer struct {
+	key      previewKey
+	header   string   // Line shown above the lines, if any; not scrolled.
+	lines    []string // Lines loaded so far.
+	plain    []string // Lines loaded so far, without styling.
+	numbered bool     // Whether lines are shown with line numbers.
+	graphic  string   // Escape sequences drawing an image, if any.
+	sixel    bool     // Whether graphic is drawn over lines as Sixel graphics.
+	imageId  uint32   // Id of the kitty image drawn by graphic, if any.
+	loading  bool     // Whether lines are being loaded in the background.
+	release  func()   // Releases the file kept open to load lines, if any.
+	toEnd    bool     // Whether to load all lines and scroll to the end.
+	// searching is whether lines loaded in the background are searched for
+	// the next match.
+	searching bool
+	// next loads the next lines. It is nil once all lines are loaded.
+	next func() (lines, plain []string, done bool)
+}
+
+// load loads lines until at least n lines or all lines are loaded, unless
+// lines are being loaded in the background.
+func (b *previewBuffer) load(n int) {
+	for len(b.lines) < n && b.next != nil && !b.loading {
+		lines, plain, done := b.next()
+		b.lines = append(b.lines, lines...)
+		b.plain = append(b.plain, plain...)
+		if done {
+			b.next = nil
+		}
+	}
+}
+
+// previewLinesMsg is sent when lines of a preview are loaded in the
+// background.
+type previewLinesMsg struct {
+	buf          *previewBuffer
+	lines, plain []string
+	done         bool
+}
+
+// loadMore returns a command loading the next previewLoadChunks chunks of b
+// in the background.
+func (b *previewBuffer) loadMore() tea.Cmd {
+	next := b.next
+	b.loading = true
+	return func() tea.Msg {
+		msg := previewLinesMsg{buf: b}
+		for i := 0; i < previewLoadChunks && !msg.done; i++ {
+			lines, plain, done := next()
+			msg.lines = append(msg.lines, lines...)
+			msg.plain = append(msg.plain, plain...)
+			msg.done = done
+		}
+		return msg
+	}
+}
+
+// receivePreviewLines adds the lines loaded in the background to their
+// preview, and returns a command loading more if it is scrolled to the end.
+func (m *Model) receivePreviewLines(msg previewLinesMsg) tea.Cmd {
+	b := msg.buf
+	b.loading = false
+	from := len(b.lines)
+	b.lines = append(b.lines, msg.lines...)
+	b.plain = append(b.plain, msg.plain...)
+	if msg.done {
+		b.next = nil
+	}
+	if b.searching {
+		switch {
+		case b != m.previewBuf:
+			b.searching = false
+		case m.findPreviewMatch(from, 1):
+			b.searching = false
+		case b.next == nil:
+			b.searching = false
+			m.notice = fmt.Sprintf("not found: %v", m.previewSearch)
+		default:
+			return b.loadMore()
+		}
+	}
+	if !b.toEnd {
+		return nil
+	}
+	if b.next != nil {
+		return b.loadMore()
+	}
+	b.toEnd = false
+	if b == m.previewBuf {
+		m.scrollPreview(len(b.lines))
+	}
+	return nil
+}
+
+// previewCacheSize is the number of recently generated previews kept.
+const previewCacheSize = 32
+
+// previewCache keeps the most recently used previews. The zero value is an
+// empty cache.
+type previewCache struct {
+	order *list.List // Values are *previewBuffer, most recently used first.
+	items map[previewKey]*list.Element
+}
+
+// get returns the cached preview identified by id, if any.
+func (c *previewCache) get(id previewKey) (*previewBuffer, bool) {
+	e, ok := c.items[id]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*previewBuffer), true
+}
+
+// put caches b, evicting the least recently used preview if full.
+func (c *previewCache) put(b *previewBuffer) {
+	if c.items == nil {
+		c.order = list.New()
+		c.items = make(map[previewKey]*list.Element)
+	}
+	if e, ok := c.items[b.key]; ok {
+		e.Value = b
+		c.order.MoveToFront(e)
+		return
+	}
+	c.items[b.key] = c.order.PushFront(b)
+	if c.order.Len() > previewCacheSize {
+		c.remove(c.order.Back())
+	}
+}
+
+// removeArchive removes the previews of files in archive a.
+func (c *previewCache) removeArchive(a *archive) {
+	for id, e := range c.items {
+		if id.archive == a {
+			c.remove(e)
+		}
+	}
+}
+
+// remove removes the cached preview e, releasing its file.
+func (c *previewCache) remove(e *list.Element) {
+	b := c.order.Remove(e).(*previewBuffer)
+	delete(c.items, b.key)
+	if b.release != nil {
+		b.release()
+	}
+}
+
+// previewSource is the file a preview is generated from off the UI goroutine,
+// with copies of the settings the preview depends on, as the Model is
+// modified meanwhile.
+type previewSource struct {
+	fsys        fs.FS  // File system containing the file; nil for the operating system.
+	name        string // Name of the file in fsys, or its path.
+	info        fs.FileInfo
+	st          Styles
+	syntaxStyle string
+	lineNumbers bool
+	protocol    ImageProtocol // How images are drawn; never ImageAuto.
+	cellWidth   int           // Size of a terminal cell in pixels.
+	cellHeight  int
+}
+
+// previewSource returns the source of the preview of the file at path.
+func (m *Model) previewSource(path string, info fs.FileInfo) *previewSource {
+	fsys, name := m.fsName(path)
+	src := &previewSource{
+		fsys:        fsys,
+		name:        name,
+		info:        info,
+		st:          *m.st,
+		syntaxStyle: m.syntaxStyle,
+		lineNumbers: m.lineNumbers,
+		protocol:    m.imageProtocol,
+	}
+	if src.protocol == ImageAuto {
+		src.protocol = detectImageProtocol()
+	}
+	src.cellWidth, src.cellHeight = cellSize(m.output.w)
+	return src
+}
+
+// open opens the file for reading.
+func (src *previewSource) open() (fs.File, error) {
+	if src.fsys != nil {
+		return src.fsys.Open(src.name)
+	}
+	return os.Open(src.name)
+}
+
+// readFile reads the file.
+func (src *previewSource) readFile() ([]byte, error) {
+	if src.fsys != nil {
+		return fs.ReadFile(src.fsys, src.name)
+	}
+	return os.ReadFile(src.name)
+}
+
+// chunkReader reads a file in chunks of previewChunkSize bytes. Files that
+// can only be read sequentially, such as files in compressed archives, are
+// kept open between chunks, until closed.
+type chunkReader struct {
+	src    *previewSource
+	mu     sync.Mutex // Guards file, as chunks are read in the background.
+	file   fs.File    // File read sequentially, if open.
+	offset int64      // Number of bytes read.
+}
+
+// read reads the next chunk, and reports whether the end of file is reached.
+func (r *chunkReader) read() ([]byte, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	chunk := make([]byte, previewChunkSize)
+	if r.file == nil {
+		file, err := r.src.open()
+		if err != nil {
+			return nil, true, err
+		}
+		if ra, ok := file.(io.ReaderAt); ok {
+			n, err := ra.ReadAt(chunk, r.offset)
+			file.Close()
+			return r.chunk(chunk, n, err)
+		}
+		// Skip the chunks read before the file was closed.
+		if _, err := io.CopyN(io.Discard, file, r.offset); err != nil {
+			file.Close()
+			return r.chunk(chunk, 0, err)
+		}
+		r.file = file
+	}
+	n, err := io.ReadFull(r.file, chunk)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	if err != nil {
+		r.file.Close()
+		r.file = nil
+	}
+	return r.chunk(chunk, n, err)
+}
+
+// chunk returns the first n bytes read into chunk, given the error of
+// reading them.
+func (r *chunkReader) chunk(chunk []byte, n int, err error) ([]byte, bool, error) {
+	r.offset += int64(n)
+	if err != nil && err != io.EOF {
+		return nil, true, err
+	}
+	return chunk[:n], err == io.EOF, nil
+}
+
+// close closes the file, if open. It is opened again to read more chunks.
+func (r *chunkReader) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file != nil {
+		r.file.Close()
+		r.file = nil
+	}
+}
+
+// previewMsg is sent when the preview of a file is generated.
+type previewMsg struct {
+	buf *previewBuffer
+}
+
+// schedulePreview returns a command generating the preview of the file under
+// the cursor, unless it is shown, cached or being generated already. The
+// generation of a preview of any other file is cancelled.
+func (m *Model) schedulePreview() tea.Cmd {
+	if !m.previewMode {
+		return nil
+	}
+	filePath, ok := m.filePath()
+	if !ok {
+		return nil
+	}
+	fileInfo, err := m.stat(filePath)
+	if err != nil {
+		return nil
+	}
+
+	id := previewKey{
+		path:        filePath,
+		modTime:     fileInfo.ModTime(),
+		size:        fileInfo.Size(),
+		width:       m.width / 2,
+		height:      m.height - 1, // Subtract 1 for name bar.
+		raw:         m.markdownRaw,
+		hidden:      m.showHidden,
+		sortMode:    m.sortMode,
+		sortReverse: m.sortReverse,
+	}
+	if m.archive != nil {
+		if _, ok := m.archive.name(filePath); ok {
+			id.archive = m.archive
+		}
+	}
+	if m.previewBuf != nil && m.previewBuf.key == id || m.previewPending == id {
+		return nil
+	}
+	if b, ok := m.previewCache.get(id); ok {
+		m.cancelPreview()
+		m.showPreview(b)
+		return nil
+	}
+
+	m.cancelPreview()
+	ctx, cancel := context.WithCancel(context.Background())
+	m.previewPending, m.previewCancel = id, cancel
+	if fileInfo.IsDir() {
+		// Listed by a copy of the receiver, as Update may change it meanwhile.
+		lister := *m
+		lister.links = nil // Only read for the current directory.
+		return func() tea.Msg {
+			b := lister.directoryPreview(id)
+			if ctx.Err() != nil {
+				return nil
+			}
+			return previewMsg{b}
+		}
+	}
+	src := m.previewSource(filePath, fileInfo)
+	return func() tea.Msg {
+		b := src.generate(ctx, id)
+		if ctx.Err() != nil {
+			return nil
+		}
+		b.load(id.height)
+		return previewMsg{b}
+	}
+}
+
+// cancelPreview cancels the generation of a preview, if any.
+func (m *Model) cancelPreview() {
+	if m.previewCancel != nil {
+		m.previewCancel()
+	}
+	m.previewPending, m.previewCancel = previewKey{}, nil
+}
+
+// receivePreview caches the generated preview and shows it, unless it was
+// cancelled meanwhile.
+func (m *Model) receivePreview(msg previewMsg) {
+	if msg.buf.key != m.previewPending {
+		return
+	}
+	m.cancelPreview()
+	m.previewCache.put(msg.buf)
+	m.showPreview(msg.buf)
+}
+
+// showPreview shows b in the preview pane, scrolled to the top.
+func (m *Model) showPreview(b *previewBuffer) {
+	if m.previewBuf != nil {
+		m.previewBuf.toEnd = false
+		m.previewBuf.searching = false
+	}
+	m.previewBuf = b
+	m.previewOffset = 0
+	m.previewMatch = -1
+}
+
+// onlyLine sets the lines of b to line, e.g., an error, and returns b.
+func (b *previewBuffer) onlyLine(line string) *previewBuffer {
+	b.lines = Split(line, "\n")
+	b.plain = b.lines
+	return b
+}
+
+// directoryPreview returns the listing of the directory identified by id.
+func (m *Model) directoryPreview(id previewKey) *previewBuffer {
+	b := &previewBuffer{key: id}
+	files, err := m.readDir(id.path)
+	if err != nil {
+		return b.onlyLine(err.Error())
+	}
+	files = m.visible(id.path, files)
+	sortFiles(files, id.sortMode, id.sortReverse)
+
+	names, rows, columns := m.wrap(id.path, files, id.width, id.height, nil)
+
+	output := make([]string, rows)
+	for j := 0; j < rows; j++ {
+		row := make([]string, columns)
+		for i := 0; i < columns; i++ {
+			row[i] = names[i][j]
+		}
+		output[j] = Join(row, separator)
+	}
+	return b.onlyLine(Join(output, "\n"))
+}
+
+// generate returns the preview of the file identified by id. If ctx is
+// cancelled meanwhile, the preview returned is incomplete.
+func (src *previewSource) generate(ctx context.Context, id previewKey) *previewBuffer {
+	b := &previewBuffer{key: id}
+	width, height := id.width, id.height
+	if ctx.Err() != nil {
+		return b
+	}
+
+	if config, format, err := src.imageConfig(); err == nil {
+		return src.imagePreview(ctx, b, config, format, width, height)
+	}
+
+	r := &chunkReader{src: src}
+	content, eof, err := r.read()
+	if err != nil {
+		return b.onlyLine(err.Error())
+	}
+	b.release = r.close
+	// Loaders read the first chunk again, without reading it from the file.
+	first := true
+	read := func() ([]byte, bool, error) {
+		if first {
+			first = false
+			return content, eof, nil
+		}
+		return r.read()
+	}
+	// Text containing NUL bytes is most likely binary data, e.g., UTF-16.
+	text := utf8.Valid(trimPartialRune(content)) && bytes.IndexByte(content, 0) < 0
+	if !text {
+		b.header = src.st.Header.Render(fmt.Sprintf("%v, %v",
+			fileType(content), humanSize(src.info.Size())))
+		b.next = src.hexLoader(read, width)
+		return b
+	}
+
+	if isMarkdownExt(id.path) && !id.raw && src.info.Size() <= maxMarkdownSize {
+		content, err := src.readFile()
+		if err == nil && ctx.Err() == nil {
+			lines, err := renderMarkdown(sanitize(content), width)
+			if err == nil {
+				b.lines = lines
+				b.plain = make([]string, len(lines))
+				for i, line := range lines {
+					b.plain[i] = ansiEscape.ReplaceAllString(line, "")
+				}
+				return b
+			}
+		}
+	}
+
+	b.numbered = src.lineNumbers
+	b.next = src.textLoader(read, lexer(id.path, string(content)))
+	return b
+}
+
+// textLoader returns a function loading the next lines of the text file read
+// by read, highlighted by lexer.
+func (src *previewSource) textLoader(read func() ([]byte, bool, error), lexer chroma.Lexer) func() ([]string, []string, bool) {
+	var rest []byte      // Incomplete last line of previous chunk.
+	var carried []string // Lines of previous chunks not highlighted yet.
+	return func() ([]string, []string, bool) {
+		chunk, eof, err := read()
+		if err != nil {
+			return []string{src.st.Danger.Render(err.Error())}, []string{err.Error()}, true
+		}
+		chunk = append(rest[:len(rest):len(rest)], chunk...)
+		rest = nil
+		if !eof {
+			if i := bytes.LastIndexByte(chunk, '\n'); i >= 0 {
+				chunk, rest = chunk[:i+1], chunk[i+1:]
+			} else {
+				// Split a very long line, but not a rune.
+				n := len(trimPartialRune(chunk))
+				chunk, rest = chunk[:n], chunk[n:]
+			}
+		}
+		lines := carried
+		if len(chunk) > 0 {
+			text := TrimSuffix(sanitize(chunk), "\n")
+			lines = append(lines[:len(lines):len(lines)], Split(text, "\n")...)
+		}
+		if len(lines) == 0 {
+			return nil, nil, eof
+		}
+		highlighted, n := src.highlightText(lexer, lines, eof)
+		carried = lines[n:]
+		return highlighted, lines[:n], eof
+	}
+}
+
+// hexLoader returns a function loading the next lines of a hex dump of the
+// file read by read, fit in width.
+func (src *previewSource) hexLoader(read func() ([]byte, bool, error), width int) func() ([]string, []string, bool) {
+	n := hexBytesPerLine(width)
+	var offset int64
+	return func() ([]string, []string, bool) {
+		chunk, eof, err := read()
+		if err != nil {
+			return []string{src.st.Danger.Render(err.Error())}, []string{err.Error()}, true
+		}
+		lines, plain := src.hexLines(chunk, offset, n)
+		offset += int64(len(chunk))
+		return lines, plain, eof
+	}
+}
+
+// previewHeight returns the number of lines of the preview pane that are
+// scrolled.
+func (m *Model) previewHeight() int {
+	height := m.height - 1 // Subtract 1 for name bar.
+	if m.previewBuf != nil && m.previewBuf.header != "" {
+		height--
+	}
+	return max(height, 1)
+}
+
+// shownPreview returns the preview of the file under the cursor, or nil if
+// not generated yet.
+func (m *Model) shownPreview() *previewBuffer {
+	filePath, ok := m.filePath()
+	if !ok || m.previewBuf == nil || m.previewBuf.key.path != filePath {
+		return nil
+	}
+	return m.previewBuf
+}
+
+// previewView returns the visible part of the preview pane below its bar.
+func (m *Model) previewView() string {
+	b := m.shownPreview()
+	if b == nil {
+		return ""
+	}
+	end := min(m.previewOffset+m.previewHeight(), len(b.lines))
+
+	var output []string
+	if b.header != "" {
+		output = append(output, b.header)
+	}
+	digits := len(fmt.Sprint(end))
+	for i := m.previewOffset; i < end; i++ {
+		line := b.lines[i]
+		if i == m.previewMatch {
+			line = m.highlightPreviewMatch(b.plain[i])
+		}
+		if b.numbered {
+			line = m.st.LineNumber.Render(fmt.Sprintf("%*d ", digits, i+1)) + line
+		}
+		output = append(output, line)
+	}
+	return Join(output, "\n")
+}
+
+// previewPosition describes the visible lines of the preview pane, e.g.,
+// "1-40/120", with a trailing "+" if not all lines are loaded yet.
+func (m *Model) previewPosition() string {
+	b := m.shownPreview()
+	if b == nil || len(b.lines) == 0 {
+		return ""
+	}
+	end := min(m.previewOffset+m.previewHeight(), len(b.lines))
+	more := ""
+	switch {
+	case b.searching:
+		more = "+ searching…"
+	case b.toEnd:
+		more = "+ loading…"
+	case b.next != nil:
+		more = "+"
+	}
+	return fmt.Sprintf(" %d-%d/%d%v", m.previewOffset+1, end, len(b.lines), more)
+}
+
+// loadPreview returns a command loading the lines of the preview pane not
+// loaded yet in the background, if any, e.g., once the terminal is resized.
+// Only lines loaded are shown.
+func (m *Model) loadPreview() tea.Cmd {
+	b := m.shownPreview()
+	if b == nil || b.next == nil || b.loading || len(b.lines) >= m.previewOffset+m.previewHeight() {
+		return nil
+	}
+	return b.loadMore()
+}
+
+// scrollPreview scrolls the preview pane by delta lines.
+func (m *Model) scrollPreview(delta int) {
+	b := m.previewBuf
+	if b == nil {
+		return
+	}
+	height := m.previewHeight()
+	offset := max(m.previewOffset+delta, 0)
+	b.load(offset + height)
+	m.previewOffset = max(min(offset, len(b.lines)-height), 0)
+}
+
+// previewQuery returns the pattern matching the text searched in the preview
+// pane, ignoring case.
+func (m *Model) previewQuery() *regexp.Regexp {
+	return regexp.MustCompile("(?i)" + regexp.QuoteMeta(m.previewSearch))
+}
+
+// searchPreview scrolls the preview pane to the next line matching the text
+// searched, or to the previous line if dir is negative. If no line loaded so
+// far matches, it returns a command searching the next lines as they are
+// loaded in the background.
+func (m *Model) searchPreview(dir int) tea.Cmd {
+	b := m.previewBuf
+	if b == nil || m.previewSearch == "" {
+		return nil
+	}
+	i := m.previewOffset
+	if m.previewMatch >= 0 {
+		i = m.previewMatch + dir
+	}
+	if m.findPreviewMatch(i, dir) {
+		return nil
+	}
+	if dir < 0 || b.next == nil {
+		m.notice = fmt.Sprintf("not found: %v", m.previewSearch)
+		return nil
+	}
+	b.searching = true
+	if b.loading {
+		return nil // Searched when the lines being loaded are received.
+	}
+	return b.loadMore()
+}
+
+// findPreviewMatch scrolls the preview pane to the first line loaded from
+// line i on, in direction dir, matching the text searched, and reports
+// whether one is found.
+func (m *Model) findPreviewMatch(i, dir int) bool {
+	b := m.previewBuf
+	query := m.previewQuery()
+	for ; i >= 0 && i < len(b.plain); i += dir {
+		if query.MatchString(b.plain[i]) {
+			m.previewMatch = i
+			if i < m.previewOffset || i >= m.previewOffset+m.previewHeight() {
+				m.previewOffset = i
+				m.scrollPreview(0)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// highlightPreviewMatch returns line, the plain text of the line matching
+// the text searched in the preview pane, with the matches highlighted.
+func (m *Model) highlightPreviewMatch(line string) string {
+	var sb Builder
+	last := 0
+	for _, match := range m.previewQuery().FindAllStringIndex(line, -1) {
+		sb.WriteString(line[last:match[0]])
+		sb.WriteString(m.st.Match.Render(line[match[0]:match[1]]))
+		last = match[1]
+	}
+	sb.WriteString(line[last:])
+	return sb.String()
+}
+
+// updatePreview handles key presses while the preview pane is focused.
+func (m *Model) updatePreview(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.notice = ""
+	height := m.previewHeight()
+	if m.previewBuf != nil {
+		// Stop scrolling to the end, or searching, on any key.
+		m.previewBuf.toEnd = false
+		m.previewBuf.searching = false
+	}
+	switch {
+	case key.Matches(msg, m.keys.ForceQuit):
+		m.status = 2
+		m.dontDoPendingDeletions()
+		return m, m.quit()
+
+	case key.Matches(msg, m.keys.Focus, m.keys.Quit, m.keys.QuitQ, m.keys.Back):
+		m.previewFocus = false
+
+	case key.Matches(msg, m.keys.Up, m.keys.VimUp):
+		m.scrollPreview(-1)
+
+	case key.Matches(msg, m.keys.Down, m.keys.VimDown):
+		m.scrollPreview(1)
+
+	case key.Matches(msg, m.keys.PageUp, m.keys.Left, m.keys.VimLeft):
+		m.scrollPreview(-height)
+
+	case key.Matches(msg, m.keys.PageDown, m.keys.Right, m.keys.VimRight):
+		m.scrollPreview(height)
+
+	case key.Matches(msg, m.keys.Top, m.keys.VimTop, m.keys.Home):
+		m.previewOffset = 0
+
+	case key.Matches(msg, m.keys.Bottom, m.keys.VimBottom, m.keys.End):
+		// Load the rest in the background, as it may take long.
+		b := m.previewBuf
+		if b == nil {
+			break
+		}
+		if b.next == nil {
+			m.scrollPreview(len(b.lines))
+			break
+		}
+		b.toEnd = true
+		if !b.loading {
+			return m, b.loadMore()
+		}
+
+	case key.Matches(msg, m.keys.Search):
+		m.startInput(inputPreviewSearch, "/", m.previewSearch)
+
+	case key.Matches(msg, m.keys.NextMatch):
+		return m, m.searchPreview(1)
+
+	case key.Matches(msg, m.keys.PrevMatch):
+		return m, m.searchPreview(-1)
+	}
+	return m, nil
+}
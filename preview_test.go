@@ -0,0 +1,204 @@
+package walk
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"reflect"
+	. "strings"
+	"testing"
+	"testing/fstest"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// logFS returns a file system with a text file of n numbered lines.
+func logFS(n int) fstest.MapFS {
+	var sb Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&sb, "line %d\n", i)
+	}
+	return fstest.MapFS{"log.txt": {Data: []byte(sb.String())}}
+}
+
+func TestPreviewBottom(t *testing.T) {
+	const lines = 300000
+	m := New(FS(logFS(lines)), Size(80, 24))
+	m.Init()
+	send(m, press("`"))
+	send(m, press("f"))
+	if m.shownPreview() == nil {
+		t.Fatal("preview not shown")
+	}
+
+	_, cmd := m.Update(press("end"))
+	if cmd == nil {
+		t.Fatal("no command loading lines")
+	}
+	if !Contains(m.previewPosition(), "loading") {
+		t.Errorf("position = %q, want loading indicator", m.previewPosition())
+	}
+	run(m, cmd)
+	if b := m.previewBuf; b.next != nil || len(b.lines) != lines {
+		t.Fatalf("%d lines loaded, want %d", len(b.lines), lines)
+	}
+	view := Split(m.previewView(), "\n")
+	if last := view[len(view)-1]; !HasSuffix(last, fmt.Sprintf("line %d", lines)) {
+		t.Errorf("last line shown = %q", last)
+	}
+}
+
+func TestPreviewBottomStop(t *testing.T) {
+	m := New(FS(logFS(300000)), Size(80, 24))
+	m.Init()
+	send(m, press("`"))
+	send(m, press("f"))
+
+	_, cmd := m.Update(press("end"))
+	send(m, press("k")) // Any key stops loading.
+	run(m, cmd)
+	if b := m.previewBuf; b.loading || b.next == nil {
+		t.Errorf("loading = %v, all loaded = %v", b.loading, b.next == nil)
+	}
+}
+
+func TestPreviewViewLoadsNothing(t *testing.T) {
+	m := New(FS(logFS(300000)), Size(80, 24))
+	m.Init()
+	send(m, press("`"))
+	b := m.shownPreview()
+	if b == nil {
+		t.Fatal("preview not shown")
+	}
+	var calls int
+	next := b.next
+	b.next = func() ([]string, []string, bool) {
+		calls++
+		return next()
+	}
+	m.previewOffset = len(b.lines) // As if more lines were shown.
+	m.View()
+	if calls > 0 {
+		t.Fatalf("lines loaded %d times when rendered", calls)
+	}
+	_, cmd := m.Update(struct{}{})
+	if !b.loading {
+		t.Error("lines shown not loaded in the background")
+	}
+	run(m, cmd)
+	if calls == 0 || len(b.lines) < m.previewOffset+m.previewHeight() {
+		t.Errorf("%d lines loaded in %d calls", len(b.lines), calls)
+	}
+}
+
+func TestPreviewDirectory(t *testing.T) {
+	m := New(FS(testFS()), Size(80, 24))
+	m.Init()
+	moveTo(t, m, "dir")
+	_, cmd := m.Update(press("`"))
+	if m.shownPreview() != nil {
+		t.Fatal("directory listed in Update")
+	}
+	run(m, cmd)
+	b := m.shownPreview()
+	if b == nil {
+		t.Fatal("preview not shown")
+	}
+	if got := Join(b.plain, "\n"); !Contains(got, "a.txt") || !Contains(got, "b.txt") {
+		t.Errorf("preview = %q", got)
+	}
+}
+
+func TestPreviewSearch(t *testing.T) {
+	m := New(FS(logFS(300000)), Size(80, 24))
+	m.Init()
+	send(m, press("`"))
+	send(m, press("f"))
+	send(m, press("/"))
+	send(m, press("line 250000"))
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("no command searching lines")
+	}
+	if !Contains(m.previewPosition(), "searching") {
+		t.Errorf("position = %q, want searching indicator", m.previewPosition())
+	}
+	run(m, cmd)
+	if m.previewMatch != 249999 || m.previewBuf.searching {
+		t.Errorf("match = %d, searching = %v", m.previewMatch, m.previewBuf.searching)
+	}
+	if m.previewBuf.next == nil {
+		t.Error("all lines loaded after the match")
+	}
+
+	// Matches before are found in the lines loaded.
+	if cmd := m.searchPreview(-1); cmd != nil || m.previewMatch != 249999 || m.notice == "" {
+		t.Errorf("match = %d, notice = %q", m.previewMatch, m.notice)
+	}
+}
+
+func TestPreviewCancelled(t *testing.T) {
+	var data bytes.Buffer
+	if err := png.Encode(&data, image.NewRGBA(image.Rect(0, 0, 64, 64))); err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{"image.png": {Data: data.Bytes()}}
+	m := New(FS(fsys), Size(80, 24), Images(ImageSixel))
+	m.Init()
+	info, err := m.stat("image.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := m.previewSource("image.png", info)
+	id := previewKey{path: "image.png", width: 40, height: 20}
+
+	if b := src.generate(context.Background(), id); b.graphic == "" {
+		t.Error("image not drawn")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if b := src.generate(ctx, id); b.graphic != "" || len(b.lines) > 0 {
+		t.Error("image drawn although cancelled")
+	}
+}
+
+func TestKittyImagesDeleted(t *testing.T) {
+	var data bytes.Buffer
+	if err := png.Encode(&data, image.NewRGBA(image.Rect(0, 0, 64, 64))); err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{
+		"a.png": {Data: data.Bytes()},
+		"b.png": {Data: data.Bytes()},
+	}
+	m := New(FS(fsys), Size(80, 24), Images(ImageKitty))
+	var out Builder
+	m.output = &syncWriter{w: &out}
+	m.Init()
+	send(m, press("`"))
+	first := m.shownPreview()
+	if first == nil || first.imageId == 0 {
+		t.Fatal("kitty image not shown")
+	}
+	moveTo(t, m, "b.png")
+	send(m, struct{}{}) // Generate and show the preview.
+	if second := m.shownPreview(); second == nil || second == first {
+		t.Fatal("second image not shown")
+	}
+	if !Contains(out.String(), kittyDelete(first.imageId)) {
+		t.Error("first image not deleted when the second is drawn")
+	}
+
+	out.Reset()
+	_, quit := m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	cmds := reflect.ValueOf(quit()) // The commands run in sequence.
+	for i := 0; i < cmds.Len(); i++ {
+		cmds.Index(i).Interface().(tea.Cmd)()
+	}
+	if !Contains(out.String(), kittyDelete(m.previewBuf.imageId)) {
+		t.Errorf("last image not deleted on exit, output = %q", out.String())
+	}
+}
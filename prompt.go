@@ -0,0 +1,67 @@
+package walk
+
+import (
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// inputKind identifies what the text entered at the input prompt is used for.
+type inputKind int
+
+const (
+	inputNone          inputKind = iota
+	inputRename                  // Rename the file under the cursor.
+	inputNewFile                 // Create an empty file.
+	inputNewDir                  // Create a directory and any missing parents.
+	inputSymlink                 // Create a symbolic link to the file under the cursor.
+	inputHardLink                // Create a hard link to the file under the cursor.
+	inputPreviewSearch           // Search text in the preview pane.
+)
+
+// startInput shows the input prompt with the given prompt and initial value.
+func (m *Model) startInput(kind inputKind, prompt, value string) {
+	m.inputMode = kind
+	m.input = textinput.New()
+	m.input.Prompt = prompt
+	m.input.Cursor.Style = m.st.Cursor
+	_ = m.input.Cursor.SetMode(cursor.CursorStatic)
+	m.input.SetValue(value)
+	m.input.CursorEnd()
+	_ = m.input.Focus()
+}
+
+// updateInput handles key presses while the input prompt is shown.
+func (m *Model) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.ForceQuit):
+		m.status = 2
+		m.dontDoPendingDeletions()
+		return m, m.quit()
+
+	case key.Matches(msg, m.keys.Quit):
+		m.inputMode = inputNone
+		return m, nil
+
+	case msg.Type == tea.KeyEnter:
+		// Not using the Submit binding, which is disabled on some form fields.
+		kind := m.inputMode
+		m.inputMode = inputNone
+		m.opErr = nil
+		switch kind {
+		case inputRename:
+			m.opErr = m.rename(m.input.Value())
+		case inputNewFile, inputNewDir, inputSymlink, inputHardLink:
+			m.opErr = m.create(kind, m.input.Value())
+		case inputPreviewSearch:
+			m.previewSearch = m.input.Value()
+			m.previewMatch = -1
+			return m, m.searchPreview(1)
+		}
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
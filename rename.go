@@ -0,0 +1,210 @@
+package walk
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	. "strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// bulkRenameMsg is sent when the editor used to bulk rename files exits.
+type bulkRenameMsg struct {
+	file  string   // Temporary file listing the new names.
+	paths []string // Absolute paths of files to rename, one per line of file.
+	err   error    // Error running the editor.
+}
+
+// rename renames the file under the cursor to name, in the same directory.
+func (m *Model) rename(name string) error {
+	oldPath, ok := m.filePath()
+	if !ok {
+		return nil
+	}
+	if name == "" || name == "." || name == ".." || ContainsAny(name, "/"+fileSeparator) {
+		return fmt.Errorf("invalid file name: %q", name)
+	}
+	newPath := m.join(m.path, name)
+	if newPath == oldPath {
+		return nil
+	}
+	if _, err := m.lstat(newPath); err == nil {
+		return fmt.Errorf("cannot rename to %v: file exists", name)
+	}
+	if err := m.renameFile(oldPath, newPath); err != nil {
+		return err
+	}
+	m.renameMark(oldPath, newPath)
+	m.list()
+	m.prevName = name
+	m.findPrevName = true
+	return nil
+}
+
+// renameMark moves the mark of a renamed file, if any, to its new path.
+func (m *Model) renameMark(oldPath, newPath string) {
+	if m.marks[oldPath] {
+		delete(m.marks, oldPath)
+		m.marks[newPath] = true
+	}
+}
+
+// inlineInput renders the input prompt in place of cell, the wrapped name of
+// the n-th file, keeping any icon in front of the name.
+func (m *Model) inlineInput(cell string, n int) string {
+	prefix := ""
+	if n < len(m.files) {
+		prefix = cell[:max(Index(cell, m.files[n].Name()), 0)]
+	}
+	input := m.input
+	// Leave room for the cursor at end of input.
+	input.Width = max(lipgloss.Width(cell)-lipgloss.Width(prefix)-1, 1)
+	return prefix + input.View()
+}
+
+// bulkRename writes the paths of the marked files, or of the file under the
+// cursor, to a temporary file and opens it with the "open file" command. Each
+// line can then be edited to rename the file it names.
+func (m *Model) bulkRename() tea.Cmd {
+	paths := m.Values()
+	if len(paths) == 0 {
+		return nil
+	}
+	file, err := os.CreateTemp("", "lk-rename-*.txt")
+	if err != nil {
+		m.opErr = err
+		return nil
+	}
+	for _, p := range paths {
+		if _, err = fmt.Fprintln(file, m.relPath(p)); err != nil {
+			break
+		}
+	}
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+		m.opErr = err
+		return nil
+	}
+	return m.execCommand(file.Name(), func(err error) tea.Msg {
+		return bulkRenameMsg{file: file.Name(), paths: paths, err: err}
+	})
+}
+
+// relPath returns path relative to the current directory, if possible.
+func (m *Model) relPath(p string) string {
+	rel, err := filepath.Rel(m.path, p)
+	if err != nil {
+		return p
+	}
+	return rel
+}
+
+// applyBulkRename renames the files listed in msg to the names read from its
+// file. No file is renamed if any new name is empty, collides with another new
+// name, or names an existing file that is not renamed itself, and files
+// renamed are renamed back if renaming any other fails. It returns the old and
+// new paths of each file renamed.
+func (m *Model) applyBulkRename(msg bulkRenameMsg) (changes [][2]string, err error) {
+	defer os.Remove(msg.file)
+	if msg.err != nil {
+		return nil, msg.err
+	}
+	content, err := os.ReadFile(msg.file)
+	if err != nil {
+		return nil, err
+	}
+	lines := Split(TrimRight(string(content), "\r\n"), "\n")
+	if len(lines) != len(msg.paths) {
+		return nil, fmt.Errorf("rename: expected %d names but found %d",
+			len(msg.paths), len(lines))
+	}
+
+	renamed := make(map[string]string) // Map of new path to old path.
+	sources := make(map[string]bool)
+	for i, line := range lines {
+		line = TrimSuffix(line, "\r")
+		if TrimSpace(line) == "" {
+			return nil, fmt.Errorf("rename: empty name on line %d", i+1)
+		}
+		newPath := line
+		if !filepath.IsAbs(newPath) {
+			newPath = filepath.Join(m.path, newPath)
+		}
+		newPath = filepath.Clean(newPath)
+		oldPath := msg.paths[i]
+		if newPath == oldPath {
+			continue
+		}
+		if other, ok := renamed[newPath]; ok {
+			return nil, fmt.Errorf("rename: %v and %v both renamed to %v",
+				m.relPath(other), m.relPath(oldPath), m.relPath(newPath))
+		}
+		renamed[newPath] = oldPath
+		sources[oldPath] = true
+		changes = append(changes, [2]string{oldPath, newPath})
+	}
+	for _, change := range changes {
+		_, err := os.Lstat(change[1])
+		if err == nil && !sources[change[1]] {
+			return nil, fmt.Errorf("rename: %v exists", m.relPath(change[1]))
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	// Rename in two phases via temporary names, so that files swapping names
+	// (or renamed in a cycle) don't clobber each other.
+	temps := make([]string, len(changes))
+	for i, change := range changes {
+		temps[i] = uniquePath(change[0] + ".lk-rename")
+		if err := os.Rename(change[0], temps[i]); err != nil {
+			for j := i - 1; j >= 0; j-- {
+				_ = os.Rename(temps[j], changes[j][0])
+			}
+			return nil, err
+		}
+	}
+	for i, change := range changes {
+		err := os.MkdirAll(filepath.Dir(change[1]), 0o755)
+		if err == nil {
+			err = os.Rename(temps[i], change[1])
+		}
+		if err != nil {
+			// Move files renamed back to their temporary names first, as
+			// they may have taken the names of files not renamed yet.
+			for j := i - 1; j >= 0; j-- {
+				_ = os.Rename(changes[j][1], temps[j])
+			}
+			for j := range changes {
+				_ = os.Rename(temps[j], changes[j][0])
+			}
+			return nil, err
+		}
+	}
+	for _, change := range changes {
+		m.renameMark(change[0], change[1])
+	}
+	return changes, nil
+}
+
+// renameSummary describes the given changes made by applyBulkRename.
+func (m *Model) renameSummary(changes [][2]string) string {
+	const maxListed = 3
+	list := make([]string, 0, maxListed)
+	for i, change := range changes {
+		if i == maxListed {
+			list = append(list, "…")
+			break
+		}
+		list = append(list, m.relPath(change[0])+" → "+m.relPath(change[1]))
+	}
+	return fmt.Sprintf("renamed %d file(s): %v", len(changes), Join(list, ", "))
+}
@@ -0,0 +1,70 @@
+package walk
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	. "strings"
+	"testing"
+)
+
+func TestApplyBulkRename(t *testing.T) {
+	tests := []struct {
+		name  string
+		files string // Files renamed, and their new names.
+		names string
+		want  string // Name of each file after renaming, by its content.
+		err   string
+	}{
+		{"swap", "a b c", "b a c", "a=b b=a c=c d=d", ""},
+		{"cycle", "a b c", "b c a", "a=b b=c c=a d=d", ""},
+		{"subdirectory", "a", "e/a", "a=e/a b=b c=c d=d", ""},
+		{"collision", "a b", "c c", "a=a b=b c=c d=d", "both renamed to c"},
+		{"existing", "a b", "b d", "a=a b=b c=c d=d", "d exists"},
+		{"empty", "a b", " a", "a=a b=b c=c d=d", "empty name on line 1"},
+		// Renaming c below x fails once d is renamed to x, after a has taken
+		// the name of b.
+		{"rollback", "a d c b", "b x x/y a", "a=a b=b c=c d=d", "not a directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range Fields("a b c d") {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			m := New(Path(dir), Size(80, 24))
+			msg := bulkRenameMsg{file: filepath.Join(t.TempDir(), "names")}
+			for _, name := range Fields(tt.files) {
+				msg.paths = append(msg.paths, filepath.Join(dir, name))
+			}
+			names := Join(Split(tt.names, " "), "\n") + "\n"
+			if err := os.WriteFile(msg.file, []byte(names), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := m.applyBulkRename(msg)
+			if tt.err == "" && err != nil || tt.err != "" && (err == nil || !Contains(err.Error(), tt.err)) {
+				t.Errorf("error = %v, want %q", err, tt.err)
+			}
+			var got []string
+			err = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() {
+					return err
+				}
+				content, err := os.ReadFile(p)
+				rel, _ := filepath.Rel(dir, p)
+				got = append(got, string(content)+"="+filepath.ToSlash(rel))
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sort.Strings(got)
+			if Join(got, " ") != tt.want {
+				t.Errorf("files %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
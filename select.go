@@ -0,0 +1,132 @@
+package walk
+
+import (
+	"sort"
+)
+
+// cursorIndex returns the index into files of the file under the cursor.
+func (m *Model) cursorIndex() int {
+	return m.c*m.rows + m.r
+}
+
+// visualRange returns the inclusive bounds of the active visual range, which
+// spans the files between the anchor and the cursor in listing order.
+func (m *Model) visualRange() (lo, hi int) {
+	lo, hi = m.visualAnchor, m.cursorIndex()
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	lo = max(lo, 0)
+	hi = min(hi, len(m.files)-1)
+	return lo, hi
+}
+
+// toggleVisual starts a visual range at the cursor, or ends the active one
+// and marks every file in it.
+func (m *Model) toggleVisual() {
+	if m.visualMode {
+		m.commitVisual()
+		return
+	}
+	if len(m.files) == 0 {
+		return
+	}
+	m.visualMode = true
+	m.visualAnchor = m.cursorIndex()
+}
+
+// commitVisual ends the active visual range, if any, and marks every file in
+// it.
+func (m *Model) commitVisual() {
+	if !m.visualMode {
+		return
+	}
+	lo, hi := m.visualRange()
+	for i := lo; i <= hi; i++ {
+		m.marks[m.join(m.path, m.files[i].Name())] = true
+	}
+	m.visualMode = false
+}
+
+// toggleMark marks the file under the cursor, or unmarks it if already marked.
+func (m *Model) toggleMark() bool {
+	filePath, ok := m.filePath()
+	if !ok {
+		return false
+	}
+	if m.marks[filePath] {
+		delete(m.marks, filePath)
+	} else {
+		m.marks[filePath] = true
+	}
+	return true
+}
+
+// markAll marks every file listed.
+func (m *Model) markAll() {
+	m.visualMode = false
+	for _, file := range m.files {
+		m.marks[m.join(m.path, file.Name())] = true
+	}
+}
+
+// invertMarks marks every file listed that is not marked and unmarks every
+// file listed that is.
+func (m *Model) invertMarks() {
+	m.commitVisual()
+	for _, file := range m.files {
+		filePath := m.join(m.path, file.Name())
+		if m.marks[filePath] {
+			delete(m.marks, filePath)
+		} else {
+			m.marks[filePath] = true
+		}
+	}
+}
+
+// clearMarks unmarks every file, including those in other directories.
+func (m *Model) clearMarks() {
+	m.visualMode = false
+	m.marks = make(map[string]bool)
+}
+
+// pruneMarks unmarks the files of the current directory that are no longer
+// listed, e.g., as they were deleted or moved meanwhile.
+func (m *Model) pruneMarks() {
+	listed := make(map[string]bool, len(m.listing))
+	for _, file := range m.listing {
+		listed[file.Name()] = true
+	}
+	for filePath := range m.marks {
+		if m.dir(filePath) == m.path && !listed[m.base(filePath)] {
+			delete(m.marks, filePath)
+		}
+	}
+}
+
+// markedSet returns the absolute paths of all selected files, either by an
+// explicit mark or by the active visual range.
+func (m *Model) markedSet() map[string]bool {
+	set := make(map[string]bool, len(m.marks))
+	for filePath := range m.marks {
+		set[filePath] = true
+	}
+	if m.visualMode {
+		lo, hi := m.visualRange()
+		for i := lo; i <= hi; i++ {
+			set[m.join(m.path, m.files[i].Name())] = true
+		}
+	}
+	return set
+}
+
+// marked returns the sorted absolute paths of all selected files.
+func (m *Model) marked() []string {
+	set := m.markedSet()
+	paths := make([]string, 0, len(set))
+	for filePath := range set {
+		paths = append(paths, filePath)
+	}
+	sort.Strings(paths)
+	return paths
+}
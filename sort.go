@@ -0,0 +1,143 @@
+package walk
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	. "strings"
+)
+
+// SortMode identifies the order in which a Model lists directory entries.
+type SortMode int
+
+// Sort modes, in the order they are cycled by the Sort key binding.
+const (
+	SortByName SortMode = iota // Natural (version-aware) name order.
+	SortBySize                 // File size.
+	SortByTime                 // Modification time.
+	SortByExt                  // File extension, then name.
+	SortByType                 // Directories first, then name.
+	sortModeCount
+)
+
+func (s SortMode) String() string {
+	switch s {
+	case SortByName:
+		return "name"
+	case SortBySize:
+		return "size"
+	case SortByTime:
+		return "time"
+	case SortByExt:
+		return "extension"
+	case SortByType:
+		return "type"
+	}
+	return "unknown"
+}
+
+// next returns the sort mode following s, wrapping around after the last.
+func (s SortMode) next() SortMode {
+	return (s + 1) % sortModeCount
+}
+
+// sortFiles sorts files in place according to the given mode. Entries that
+// compare equal by size, time, extension or type are ordered by name in
+// ascending order, regardless of reverse.
+func sortFiles(files []fs.DirEntry, mode SortMode, reverse bool) {
+	type entry struct {
+		file fs.DirEntry
+		info fs.FileInfo
+	}
+	entries := make([]entry, len(files))
+	for i, file := range files {
+		// A nil FileInfo (e.g., file removed since listing) sorts as zero.
+		info, _ := file.Info()
+		entries[i] = entry{file, info}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if reverse {
+			a, b = b, a
+		}
+		switch mode {
+		case SortByName:
+			return naturalLess(a.file.Name(), b.file.Name())
+		case SortBySize:
+			if sa, sb := fileSize(a.info), fileSize(b.info); sa != sb {
+				return sa < sb
+			}
+		case SortByTime:
+			if ta, tb := fileModTime(a.info), fileModTime(b.info); ta != tb {
+				return ta < tb
+			}
+		case SortByExt:
+			ea := ToLower(filepath.Ext(a.file.Name()))
+			eb := ToLower(filepath.Ext(b.file.Name()))
+			if ea != eb {
+				return ea < eb
+			}
+		case SortByType:
+			if da, db := a.file.IsDir(), b.file.IsDir(); da != db {
+				return da
+			}
+		}
+		// Ties are broken by name in ascending order.
+		return naturalLess(entries[i].file.Name(), entries[j].file.Name())
+	})
+	for i := range entries {
+		files[i] = entries[i].file
+	}
+}
+
+func fileSize(fi fs.FileInfo) int64 {
+	if fi == nil {
+		return 0
+	}
+	return fi.Size()
+}
+
+func fileModTime(fi fs.FileInfo) int64 {
+	if fi == nil {
+		return 0
+	}
+	return fi.ModTime().UnixNano()
+}
+
+// naturalLess reports whether a sorts before b, comparing runs of decimal
+// digits by their numeric value so that "file2" sorts before "file10".
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			da, db := digitPrefix(a), digitPrefix(b)
+			na, nb := TrimLeft(da, "0"), TrimLeft(db, "0")
+			if len(na) != len(nb) {
+				return len(na) < len(nb)
+			}
+			if na != nb {
+				return na < nb
+			}
+			// Equal values: fewer leading zeros sort first.
+			if len(da) != len(db) {
+				return len(da) < len(db)
+			}
+			a, b = a[len(da):], b[len(db):]
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+func digitPrefix(s string) string {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i]
+}
@@ -0,0 +1,30 @@
+package walk
+
+import "testing"
+
+func TestNaturalLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"file2", "file10", true},
+		{"file10", "file2", false},
+		{"file2", "file2", false},
+		{"file2", "file02", true}, // Fewer leading zeros first.
+		{"file02", "file2", false},
+		{"file00", "file0", false},
+		{"x1y2", "x1y10", true},
+		{"x2y1", "x10y1", true},
+		{"a", "a1", true},
+		{"a1", "a", false},
+		{"", "a", true},
+		{"1", "a", true},
+		{"a", "B", false}, // Letters compare by byte.
+		{"9999999999999999999999", "10000000000000000000000", true},
+	}
+	for _, tt := range tests {
+		if got := naturalLess(tt.a, tt.b); got != tt.want {
+			t.Errorf("naturalLess(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
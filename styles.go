@@ -5,7 +5,7 @@ import (
 )
 
 type Styles struct {
-	Warning, Preview, Cursor, Bar, Search, Danger lipgloss.Style
+	Warning, Preview, Cursor, Bar, Search, Danger, Match, Mark, LineNumber, Header lipgloss.Style
 }
 
 func NewStyles() *Styles { return new(Styles).Default() }
@@ -20,5 +20,9 @@ func (s *Styles) Default() *Styles {
 	s.Bar = lipgloss.NewStyle().Background(lipgloss.Color("#5C5C5C")).Foreground(lipgloss.Color("#FFFFFF"))
 	s.Search = lipgloss.NewStyle().Background(lipgloss.Color("#499F1C")).Foreground(lipgloss.Color("#FFFFFF"))
 	s.Danger = lipgloss.NewStyle().Background(lipgloss.Color("#FF0000")).Foreground(lipgloss.Color("#FFFFFF"))
+	s.Match = lipgloss.NewStyle().Foreground(lipgloss.Color("#73F59F")).Bold(true).Underline(true)
+	s.Mark = lipgloss.NewStyle().Foreground(lipgloss.Color("#F5C542")).Bold(true)
+	s.LineNumber = lipgloss.NewStyle().Foreground(lipgloss.Color("#5C5C5C"))
+	s.Header = lipgloss.NewStyle().Bold(true)
 	return s
 }
@@ -0,0 +1,154 @@
+package walk
+
+import (
+	"path/filepath"
+	. "strings"
+
+	"github.com/alecthomas/chroma"
+	"github.com/alecthomas/chroma/formatters"
+	"github.com/alecthomas/chroma/lexers"
+	"github.com/alecthomas/chroma/styles"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// defaultSyntaxStyle is the chroma style used to highlight previewed text if
+// none is configured.
+const defaultSyntaxStyle = "monokai"
+
+// lexer returns the lexer for the file named fileName, selected by its name
+// or, failing that, by analysing its content. It returns nil for plain text.
+func lexer(fileName, content string) chroma.Lexer {
+	lexer := lexers.Match(filepath.Base(fileName))
+	if lexer == nil {
+		lexer = lexers.Analyse(content)
+	}
+	if lexer == nil {
+		return nil
+	}
+	return chroma.Coalesce(lexer)
+}
+
+// formatter returns the chroma terminal formatter supporting the colors of
+// the current lipgloss color profile, or nil if colors are not supported.
+func formatter() chroma.Formatter {
+	switch lipgloss.ColorProfile() {
+	case termenv.TrueColor:
+		return formatters.TTY16m
+	case termenv.ANSI256:
+		return formatters.TTY256
+	case termenv.ANSI:
+		return formatters.TTY16
+	}
+	return nil
+}
+
+// highlightLookahead is the size of the text tokenised after the lines that
+// are highlighted, so that tokens spanning lines (e.g., comments) end in it.
+const highlightLookahead = previewChunkSize / 4
+
+// maxHighlightCarry is the size of the text kept at most for a token spanning
+// lines to end, before it is highlighted anyway.
+const maxHighlightCarry = 4 * previewChunkSize
+
+// highlightText returns the first n of lines, a UTF-8 text sanitized for the
+// terminal, syntax highlighted by lexer. Lines are returned unchanged if lexer
+// is nil or the terminal does not support colors.
+//
+// Unless lines are final, i.e., reach the end of the file, the lines from the
+// last line that lexer starts in its initial state on, before the last
+// highlightLookahead bytes, are not returned, to be highlighted along with the
+// lines that follow them.
+func (src *previewSource) highlightText(lexer chroma.Lexer, lines []string, final bool) ([]string, int) {
+	format := formatter()
+	if lexer == nil || format == nil {
+		return lines, len(lines)
+	}
+	style := styles.Get(src.syntaxStyle)
+	if style == nil {
+		style = styles.Fallback
+	}
+	content := Join(lines, "\n")
+	tokens, ok := tokenLines(lexer, content)
+	if !ok {
+		return lines, len(lines)
+	}
+	if !final {
+		last, size := len(lines), 0
+		for last > 0 && size < highlightLookahead {
+			last--
+			size += len(lines[last]) + 1
+		}
+		n := rootLine(lexer, lines[:last+1], tokens)
+		if n == 0 && len(content) < maxHighlightCarry {
+			return nil, 0
+		}
+		if n > 0 {
+			lines = lines[:n]
+		}
+	}
+	lines = append([]string(nil), lines...)
+	for i, line := range tokens {
+		if i >= len(lines) || len(line) == 0 {
+			break
+		}
+		// Format each line separately, so that every line resets its own
+		// colors, even if a token (e.g., a comment) spans lines.
+		var sb Builder
+		if err := format.Format(&sb, style, chroma.Literator(line...)); err == nil {
+			lines[i] = sb.String()
+		}
+	}
+	return lines, len(lines)
+}
+
+// tokenLines returns the tokens of content by lexer, split into lines without
+// their newlines.
+func tokenLines(lexer chroma.Lexer, content string) ([][]chroma.Token, bool) {
+	tokens, err := lexer.Tokenise(nil, content)
+	if err != nil {
+		return nil, false
+	}
+	lines := chroma.SplitTokensIntoLines(tokens.Tokens())
+	for i, line := range lines {
+		kept := line[:0]
+		for _, token := range line {
+			token.Value = TrimSuffix(token.Value, "\n")
+			if token.Value != "" {
+				kept = append(kept, token)
+			}
+		}
+		lines[i] = kept
+	}
+	return lines, true
+}
+
+// rootLine returns the index of the last of lines, tokenised as tokens, that
+// lexer starts in its initial state on, as far as can be told by tokenising it
+// alone the same way, or 0 if none but the first. Blank lines are skipped, as
+// they are tokenised the same way in most states.
+func rootLine(lexer chroma.Lexer, lines []string, tokens [][]chroma.Token) int {
+	for i := min(len(lines), len(tokens)) - 1; i > 0; i-- {
+		if TrimSpace(lines[i]) == "" {
+			continue
+		}
+		alone, ok := tokenLines(lexer, lines[i])
+		if ok && len(alone) > 0 && sameTokens(alone[0], tokens[i]) {
+			return i
+		}
+	}
+	return 0
+}
+
+// sameTokens reports whether a and b are the same tokens.
+func sameTokens(a, b []chroma.Token) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Type != b[i].Type || a[i].Value != b[i].Value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,66 @@
+package walk
+
+import (
+	"fmt"
+	. "strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+func TestHighlightChunks(t *testing.T) {
+	profile := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.TrueColor)
+	defer lipgloss.SetColorProfile(profile)
+
+	// code returns source code with a comment of n lines that spans the
+	// first chunk read.
+	code := func(n int) string {
+		var sb Builder
+		for i := 0; sb.Len() < previewChunkSize-n*10; i++ {
+			fmt.Fprintf(&sb, "int f%d(int x) { return x; }\n", i)
+		}
+		sb.WriteString("/* start of comment\n")
+		for i := 0; i < n; i++ {
+			fmt.Fprintf(&sb, "comment line %d\n", i)
+		}
+		sb.WriteString("end of comment */\nint g(void) { return 0; }\n")
+		return sb.String()
+	}
+	tests := []struct {
+		name, content string
+	}{
+		{"main.go", "package main\n" + code(100)},
+		{"main.c", code(4000)}, // Longer than the text tokenised ahead.
+	}
+	for _, tt := range tests {
+		src := &previewSource{st: *NewStyles(), syntaxStyle: defaultSyntaxStyle}
+		lexer := lexer(tt.name, tt.content)
+		want, _ := src.highlightText(lexer, Split(TrimSuffix(tt.content, "\n"), "\n"), true)
+
+		content := []byte(tt.content)
+		read := func() ([]byte, bool, error) {
+			n := min(previewChunkSize, len(content))
+			chunk := content[:n]
+			content = content[n:]
+			return chunk, len(content) == 0, nil
+		}
+		next := src.textLoader(read, lexer)
+		var got []string
+		for done := false; !done; {
+			var lines []string
+			lines, _, done = next()
+			got = append(got, lines...)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("%v: %d lines, want %d", tt.name, len(got), len(want))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%v: line %d = %q, want %q", tt.name, i+1, got[i], want[i])
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package walk
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	. "strings"
+	"time"
+)
+
+// trashInfoExt is the extension of files describing trashed files.
+const trashInfoExt = ".trashinfo"
+
+// trashTimeLayout is the layout of DeletionDate in trash info files.
+const trashTimeLayout = "2006-01-02T15:04:05"
+
+// trashEntry is a file in the trash, as described by its trash info file.
+type trashEntry struct {
+	name    string    // Name of the file in the trash.
+	path    string    // Original absolute path of the file.
+	deleted time.Time // Time the file was moved to the trash.
+}
+
+// trashDir returns the home trash directory as defined by the freedesktop.org
+// Trash specification.
+func trashDir() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "Trash"), nil
+}
+
+// moveToTrash moves the file at path to the home trash. Files residing on
+// another file system are copied to the trash and removed afterwards, or the
+// partial copy is removed if copying fails.
+func moveToTrash(path string) error {
+	dir, err := trashDir()
+	if err != nil {
+		return err
+	}
+	filesDir := filepath.Join(dir, "files")
+	infoDir := filepath.Join(dir, "info")
+	for _, d := range []string{filesDir, infoDir} {
+		if err := os.MkdirAll(d, 0o700); err != nil {
+			return err
+		}
+	}
+	path, err = filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	// Reserve a unique name by creating its info file exclusively, unless a
+	// file of that name was left in the trash without its info file.
+	base := filepath.Base(path)
+	name, info := base, (*os.File)(nil)
+	for i := 1; ; i++ {
+		infoPath := filepath.Join(infoDir, name+trashInfoExt)
+		info, err = os.OpenFile(infoPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err == nil {
+			_, err = os.Lstat(filepath.Join(filesDir, name))
+			if errors.Is(err, fs.ErrNotExist) {
+				break
+			}
+			info.Close()
+			_ = os.Remove(infoPath)
+			if err != nil {
+				return err
+			}
+		} else if !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+		name = fmt.Sprintf("%s.%d", base, i)
+	}
+	_, err = fmt.Fprintf(info, "[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		(&url.URL{Path: path}).EscapedPath(), time.Now().Format(trashTimeLayout))
+	if cerr := info.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = moveFile(path, filepath.Join(filesDir, name))
+	}
+	if err != nil {
+		_ = os.Remove(filepath.Join(infoDir, name+trashInfoExt))
+		return err
+	}
+	return nil
+}
+
+// readTrash returns the entries of the home trash, most recently deleted
+// first.
+func readTrash() ([]trashEntry, error) {
+	dir, err := trashDir()
+	if err != nil {
+		return nil, err
+	}
+	infos, err := os.ReadDir(filepath.Join(dir, "info"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []trashEntry
+	for _, info := range infos {
+		if !HasSuffix(info.Name(), trashInfoExt) {
+			continue
+		}
+		entry, err := readTrashInfo(filepath.Join(dir, "info", info.Name()))
+		if err != nil {
+			continue // Skip malformed info files.
+		}
+		entry.name = TrimSuffix(info.Name(), trashInfoExt)
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].deleted.After(entries[j].deleted)
+	})
+	return entries, nil
+}
+
+func readTrashInfo(path string) (trashEntry, error) {
+	var entry trashEntry
+	file, err := os.Open(path)
+	if err != nil {
+		return entry, err
+	}
+	defer file.Close()
+	s := bufio.NewScanner(file)
+	for s.Scan() {
+		key, val, ok := Cut(s.Text(), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Path":
+			if entry.path, err = url.PathUnescape(val); err != nil {
+				return entry, err
+			}
+		case "DeletionDate":
+			entry.deleted, _ = time.ParseInLocation(trashTimeLayout, val, time.Local)
+		}
+	}
+	if entry.path == "" {
+		return entry, fmt.Errorf("%v: missing path", path)
+	}
+	return entry, s.Err()
+}
+
+// restore moves the entry from the trash back to its original path.
+func (e trashEntry) restore() error {
+	dir, err := trashDir()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Lstat(e.path); err == nil {
+		return fmt.Errorf("cannot restore %v: file exists", e.path)
+	}
+	if err := os.MkdirAll(filepath.Dir(e.path), 0o755); err != nil {
+		return err
+	}
+	if err := moveFile(filepath.Join(dir, "files", e.name), e.path); err != nil {
+		return err
+	}
+	return os.Remove(filepath.Join(dir, "info", e.name+trashInfoExt))
+}
+
+// remove deletes the file at path, or moves it to the trash if enabled.
+func (m *Model) remove(path string) error {
+	if m.fsys != nil {
+		if fsys, ok := m.fsys.(RemoveFS); ok {
+			return fsys.RemoveAll(path)
+		}
+		return errReadOnly
+	}
+	if m.trash {
+		return moveToTrash(path)
+	}
+	return os.RemoveAll(path)
+}
+
+// openTrash shows the trash browser.
+func (m *Model) openTrash() {
+	m.trashMode = true
+	m.trashCursor = 0
+	m.trashEntries, m.opErr = readTrash()
+}
+
+// restoreTrash restores the trash entry under the cursor.
+func (m *Model) restoreTrash() {
+	if m.trashCursor >= len(m.trashEntries) {
+		return
+	}
+	entry := m.trashEntries[m.trashCursor]
+	if m.opErr = entry.restore(); m.opErr != nil {
+		return
+	}
+	m.trashEntries, m.opErr = readTrash()
+	m.trashCursor = min(m.trashCursor, max(len(m.trashEntries)-1, 0))
+	if filepath.Dir(entry.path) == m.path {
+		m.list()
+	}
+}
+
+// trashView returns the trash browser listing at most height entries.
+func (m *Model) trashView(height int) string {
+	if len(m.trashEntries) == 0 {
+		return m.st.Warning.Render("Trash is empty")
+	}
+	offset := 0
+	if m.trashCursor >= height {
+		offset = m.trashCursor - height + 1
+	}
+	userHomeDir, _ := os.UserHomeDir()
+	var output []string
+	for i := offset; i < len(m.trashEntries) && i < offset+height; i++ {
+		entry := m.trashEntries[i]
+		location := entry.path
+		if userHomeDir != "" {
+			location = Replace(location, userHomeDir, "~", 1)
+		}
+		line := entry.deleted.Format("2006-01-02 15:04") + separator + location
+		if i == m.trashCursor {
+			line = m.st.Cursor.Render(line)
+		}
+		output = append(output, line)
+	}
+	return Join(output, "\n")
+}
@@ -0,0 +1,41 @@
+package walk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMoveToTrashOrphan(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	files := filepath.Join(dir, "data", "Trash", "files")
+	if err := os.MkdirAll(files, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	// A file left in the trash without its info file.
+	if err := os.WriteFile(filepath.Join(files, "file"), []byte("orphan"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "file")
+	if err := os.WriteFile(path, []byte("data"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := moveToTrash(path); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(files, "file")); err != nil || string(data) != "orphan" {
+		t.Errorf("file left in trash = %q, %v", data, err)
+	}
+	entries, err := readTrash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].name != "file.1" || entries[0].path != path {
+		t.Fatalf("entries = %+v", entries)
+	}
+	if data, err := os.ReadFile(filepath.Join(files, "file.1")); err != nil || string(data) != "data" {
+		t.Errorf("file trashed = %q, %v", data, err)
+	}
+}
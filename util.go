@@ -4,8 +4,19 @@ import (
 	"math"
 	"os"
 	. "strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
 )
 
+// ellipsis marks where text is truncated.
+const ellipsis = "…"
+
+// minColumnWidth is the narrowest names are truncated to for more columns to
+// fit; fewer columns are laid out instead.
+const minColumnWidth = 16
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -20,14 +31,6 @@ func max(a, b int) int {
 	return b
 }
 
-func fileInfo(path string) os.FileInfo {
-	fi, err := os.Stat(path)
-	if err != nil {
-		panic(err)
-	}
-	return fi
-}
-
 func lookup(names []string, val string) string {
 	for _, name := range names {
 		val, ok := os.LookupEnv(name)
@@ -38,29 +41,76 @@ func lookup(names []string, val string) string {
 	return val
 }
 
-func leaveOnlyAscii(content []byte) string {
-	var result []byte
-
-	for _, b := range content {
-		if b == '\t' {
-			result = append(result, ' ', ' ', ' ', ' ')
-		} else if b == '\r' {
+// sanitize returns the UTF-8 text content without control characters (which
+// includes terminal escape sequences) other than newlines, and with tabs
+// expanded to spaces.
+func sanitize(content []byte) string {
+	var sb Builder
+	sb.Grow(len(content))
+	for _, r := range string(content) {
+		switch {
+		case r == '\t':
+			sb.WriteString("    ")
+		case r == '\n':
+			sb.WriteRune(r)
+		case r == utf8.RuneError, unicode.IsControl(r):
 			continue
-		} else if (b >= 32 && b <= 127) || b == '\n' { // '\n' is kept if newline needs to be retained
-			result = append(result, b)
+		default:
+			sb.WriteRune(r)
 		}
 	}
+	return sb.String()
+}
 
-	return string(result)
+// trimPartialRune returns b without any incomplete UTF-8 sequence at its end,
+// as left by reading only part of a file.
+func trimPartialRune(b []byte) []byte {
+	for i := 1; i <= utf8.UTFMax && i <= len(b); i++ {
+		if utf8.RuneStart(b[len(b)-i]) {
+			if !utf8.FullRune(b[len(b)-i:]) {
+				return b[:len(b)-i]
+			}
+			break
+		}
+	}
+	return b
 }
 
-func wrap(files []os.DirEntry, width int, height int, callback func(name string, i, j int)) ([][]string, int, int) {
+// displayName returns the name of file in directory dir as listed, preceded
+// by its icon if icons are enabled.
+func (m *Model) displayName(dir string, file os.DirEntry) string {
+	name := ""
+	if m.showIcons && m.icons != nil {
+		info, err := file.Info()
+		if err == nil {
+			osPath, _ := m.osPath(m.join(dir, file.Name()))
+			icon := m.icons.Icon(osPath, info, m.isBrokenLink(dir, file))
+			if icon != "" {
+				name += icon + " "
+			}
+		}
+	}
+	name += file.Name()
+	if file.IsDir() {
+		// Dirs should have a slash at the end.
+		name += fileSeparator
+	}
+	return name
+}
+
+// wrap lays out the names of files in directory dir, and the targets of
+// symbolic links, in as many columns as fit in width. Names wider than a
+// column are truncated in the middle.
+func (m *Model) wrap(dir string, files []os.DirEntry, width int, height int, callback func(name string, i, j int)) ([][]string, int, int) {
 	// If it's possible to fit all files in one column on a third of the screen,
 	// just use one column. Otherwise, let's squeeze listing in half of screen.
-	columns := len(files) / (height / 3)
+	columns := len(files) / max(height/3, 1)
 	if columns <= 0 {
 		columns = 1
 	}
+	// Names are first laid out as wide as the screen, and only truncated to
+	// the width of a column if they do not fit.
+	limit := width
 
 start:
 	// Let's try to fit everything in terminal width with this many columns.
@@ -76,33 +126,23 @@ start:
 		for j := 0; j < rows; j++ {
 			name := ""
 			if n < len(files) {
-				if showIcons {
-					info, err := files[n].Info()
-					if err == nil {
-						icon := icons.getIcon(info)
-						if icon != "" {
-							name += icon + " "
-						}
-					}
+				name = m.displayName(dir, files[n]) + m.linkSuffix(dir, files[n])
+				if limit > 0 && runewidth.StringWidth(name) > limit {
+					name = truncateMiddle(name, limit)
 				}
-				name += files[n].Name()
 				if callback != nil {
 					callback(files[n].Name(), i, j)
 				}
-				if files[n].IsDir() {
-					// Dirs should have a slash at the end.
-					name += fileSeparator
-				}
 				n++
 			}
-			if max < len(name) {
-				max = len(name)
+			if w := runewidth.StringWidth(name); max < w {
+				max = w
 			}
 			names[i][j] = name
 		}
 		// Append spaces to make all names in one column of same size.
 		for j := 0; j < rows; j++ {
-			names[i][j] += Repeat(" ", max-len(names[i][j]))
+			names[i][j] += Repeat(" ", max-runewidth.StringWidth(names[i][j]))
 		}
 	}
 	for j := 0; j < rows; j++ {
@@ -110,11 +150,59 @@ start:
 		for i := 0; i < columns; i++ {
 			row[i] = names[i][j]
 		}
-		if len(Join(row, separator)) > width && columns > 1 {
+		if runewidth.StringWidth(Join(row, separator)) > width && columns > 1 {
+			// Truncate names to the width of a column, if not too narrow.
+			column := (width - (columns-1)*len(separator)) / columns
+			if column < limit && column >= minColumnWidth {
+				limit = column
+				goto start
+			}
 			// Yep. No luck, let's decrease number of columns and try one more time.
 			columns--
+			limit = width
 			goto start
 		}
 	}
 	return names, rows, columns
 }
+
+// truncateMiddle returns s shortened to width terminal cells, if wider, by
+// replacing its middle with an ellipsis, e.g., "long…name.txt".
+func truncateMiddle(s string, width int) string {
+	if runewidth.StringWidth(s) <= width {
+		return s
+	}
+	if width <= 0 {
+		return ""
+	}
+	// Keep a bit more of the head, where names differ the most.
+	tail := (width - 1) / 2
+	head := width - 1 - tail
+	runes := []rune(s)
+	i, w := 0, 0
+	for ; i < len(runes) && w+runewidth.RuneWidth(runes[i]) <= head; i++ {
+		w += runewidth.RuneWidth(runes[i])
+	}
+	j, w := len(runes), 0
+	for ; j > i && w+runewidth.RuneWidth(runes[j-1]) <= tail; j-- {
+		w += runewidth.RuneWidth(runes[j-1])
+	}
+	return string(runes[:i]) + ellipsis + string(runes[j:])
+}
+
+// truncateLeft returns s shortened to width terminal cells, if wider, by
+// replacing its beginning with an ellipsis.
+func truncateLeft(s string, width int) string {
+	if runewidth.StringWidth(s) <= width {
+		return s
+	}
+	if width <= 0 {
+		return ""
+	}
+	runes := []rune(s)
+	j, w := len(runes), 0
+	for ; j > 0 && w+runewidth.RuneWidth(runes[j-1]) <= width-1; j-- {
+		w += runewidth.RuneWidth(runes[j-1])
+	}
+	return ellipsis + string(runes[j:])
+}
@@ -1,23 +1,26 @@
 package walk
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
+	"log"
 	"os"
 	"os/exec"
-	"path"
 	"path/filepath"
 	"runtime"
 	. "strings"
 	"time"
-	"unicode/utf8"
 
 	"github.com/antonmedv/clipboard"
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/sahilm/fuzzy"
+	"github.com/mattn/go-runewidth"
 )
 
 var version = "v2.2.0"
@@ -26,14 +29,12 @@ func Version() string { return version }
 
 const separator = "    " // Separator between columns.
 
-var (
-	fileSeparator = string(filepath.Separator)
-	showIcons     = false
-)
+var fileSeparator = string(filepath.Separator)
 
 type Model struct {
 	path              string              // Current dir path we are looking at.
 	files             []fs.DirEntry       // Files we are looking at.
+	listing           []fs.DirEntry       // Files in current dir before filtering.
 	err               error               // Error while listing files.
 	field             *field              // Bubble Tea Huh form field.
 	keys              *keyMap             // Key bindings.
@@ -44,23 +45,73 @@ type Model struct {
 	width, height     int                 // Terminal size.
 	offset            int                 // Scroll position.
 	positions         map[string]position // Map of cursor positions per path.
-	search            string              // Type to select files with this value.
-	searchMode        bool                // Whether type-to-select is active.
+	search            string              // Type to filter files with this value.
+	searchMode        bool                // Whether type-to-filter is active.
 	searchId          int                 // Search id to indicate what search we are currently on.
-	matchedIndexes    []int               // List of char found indexes.
+	searchTimeout     time.Duration       // Delay after which type-to-filter ends.
+	filters           map[string]string   // Map of filters per path.
+	matchedIndexes    map[string][]int    // Map of char found indexes per file name.
 	prevName          string              // Base name of previous directory before "up".
 	findPrevName      bool                // On View(), set c&r to point to prevName.
 	status            int                 // Exit code.
 	previewMode       bool                // Whether preview is active.
-	previewContent    string              // Content of preview.
+	previewBuf        *previewBuffer      // Content of preview.
+	previewOffset     int                 // First line of preview shown.
+	previewFocus      bool                // Whether preview is scrolled by keys.
+	previewSearch     string              // Text searched in preview.
+	previewMatch      int                 // Line of preview matching search.
 	deleteCurrentFile bool                // Whether to delete current file.
 	toBeDeleted       []toDelete          // Map of files to be deleted.
 	yankSuccess       bool                // Show yank info
+	sortMode          SortMode            // Order in which files are listed.
+	sortReverse       bool                // Whether sort order is descending.
+	sortChanged       bool                // Show sort info
+	showHidden        bool                // Whether hidden and ignored files are listed.
+	ignore            []ignoreRule        // Patterns of files to ignore.
+	ignoreFiles       bool                // Whether to honor .gitignore and .ignore files.
+	marks             map[string]bool     // Set of marked file paths.
+	visualMode        bool                // Whether range selection is active.
+	visualAnchor      int                 // Index of file where range selection started.
+	register          []string            // Paths of files yanked to copy or cut.
+	registerCut       bool                // Whether register files are moved on paste.
+	jobs              []*job              // Queue of copy and move jobs.
+	jobId             int                 // Id of the last job queued.
+	quitting          bool                // Whether to quit once the running job is cancelled.
+	progress          progress.Model      // Progress bar of running job.
+	notice            string              // Message shown until next key press.
+	opErr             error               // Error of last file operation.
+	trash             bool                // Whether to move deleted files to trash.
+	trashMode         bool                // Whether trash browser is active.
+	trashEntries      []trashEntry        // Files in trash.
+	trashCursor       int                 // Index of selected file in trash.
+	input             textinput.Model     // Input prompt.
+	inputMode         inputKind           // Purpose of input prompt, if shown.
+	longMode          bool                // Whether long listing is active.
+	longColumns       []Column            // Columns shown in long listing.
+	syntaxStyle       string              // Name of chroma style of text preview.
+	lineNumbers       bool                // Whether text preview shows line numbers.
+	markdownRaw       bool                // Whether Markdown preview shows source.
+	previewCache      previewCache        // Recently generated previews.
+	previewPending    previewKey          // Preview being generated.
+	previewCancel     context.CancelFunc  // Cancels preview being generated.
+	previewColumn     int                 // Screen column of preview pane.
+	imageProtocol     ImageProtocol       // How images are drawn.
+	output            *syncWriter         // Where the program is rendered.
+	graphicShown      *previewBuffer      // Preview whose image was drawn last.
+	archive           *archive            // Archive browsed as a directory, if any.
+	fsys              fs.FS               // File system browsed, if not the OS's.
+	followLinks       bool                // Whether to enter real dirs of symlinks.
+	colors            *colorMap           // Colors of files, if enabled.
+	fileColors        fileStyles          // Colors of files listed, by name.
+	links             map[string]linkInfo // Symbolic links listed, by name.
+	showIcons         bool                // Whether file type icons are shown.
+	icons             IconResolver        // Icons of files, if shown.
 }
 
 type position struct {
 	c, r   int
 	offset int
+	name   string // Selected file name, preferred over c&r when restoring.
 }
 
 type toDelete struct {
@@ -75,7 +126,16 @@ type (
 
 // New returns a new Model with the given options applied.
 func New(options ...Option[*Model]) *Model {
-	m := (&Model{positions: make(map[string]position)}).With(options...)
+	m := (&Model{
+		positions:     make(map[string]position),
+		filters:       make(map[string]string),
+		marks:         make(map[string]bool),
+		progress:      progress.New(progress.WithDefaultGradient()),
+		searchTimeout: defaultSearchTimeout,
+		syntaxStyle:   defaultSyntaxStyle,
+		colors:        defaultColors(),
+		output:        &syncWriter{w: os.Stdout},
+	}).With(options...)
 
 	// Use the default key bindings if none provided.
 	if m.keys == nil {
@@ -105,6 +165,12 @@ func Icons() Option[*Model] {
 	return func(m *Model) *Model { return m.WithIcons() }
 }
 
+// IconsFrom returns an Option that enables file type icons for a Model, as
+// returned by resolver.
+func IconsFrom(resolver IconResolver) Option[*Model] {
+	return func(m *Model) *Model { return m.WithIconResolver(resolver) }
+}
+
 // Command returns an Option that sets the "open file" command with for a Model.
 func Command(cmd string) Option[*Model] {
 	return func(m *Model) *Model { return m.WithCommand(cmd) }
@@ -115,6 +181,94 @@ func Style(styles *Styles) Option[*Model] {
 	return func(m *Model) *Model { return m.WithStyle(styles) }
 }
 
+// Sort returns an Option that sets the order in which a Model lists files.
+func Sort(mode SortMode, reverse bool) Option[*Model] {
+	return func(m *Model) *Model { return m.WithSort(mode, reverse) }
+}
+
+// Hidden returns an Option that shows hidden and ignored files in a Model.
+func Hidden() Option[*Model] {
+	return func(m *Model) *Model { return m.WithHidden(true) }
+}
+
+// Ignore returns an Option that hides files matching any of the given glob
+// patterns from a Model. Patterns use the syntax of .gitignore files, where
+// patterns containing a slash are relative to the starting path.
+func Ignore(patterns ...string) Option[*Model] {
+	return func(m *Model) *Model { return m.WithIgnore(patterns...) }
+}
+
+// IgnoreFiles returns an Option that makes a Model honor the patterns in any
+// .gitignore and .ignore files found along the current path.
+func IgnoreFiles() Option[*Model] {
+	return func(m *Model) *Model { return m.WithIgnoreFiles(true) }
+}
+
+// SearchTimeout returns an Option that sets the delay after which a Model
+// stops accepting type-to-filter input. A zero delay disables the timeout.
+func SearchTimeout(timeout time.Duration) Option[*Model] {
+	return func(m *Model) *Model { return m.WithSearchTimeout(timeout) }
+}
+
+// Trash returns an Option that makes a Model move deleted files to the trash
+// instead of removing them permanently.
+func Trash() Option[*Model] {
+	return func(m *Model) *Model { return m.WithTrash(true) }
+}
+
+// Long returns an Option that enables long listing mode for a Model, showing
+// the given metadata columns of each file, or DefaultColumns if none given.
+func Long(columns ...Column) Option[*Model] {
+	return func(m *Model) *Model { return m.WithLong(true, columns...) }
+}
+
+// SyntaxStyle returns an Option that sets the name of the chroma style used to
+// highlight text in the preview pane of a Model.
+func SyntaxStyle(name string) Option[*Model] {
+	return func(m *Model) *Model { return m.WithSyntaxStyle(name) }
+}
+
+// LineNumbers returns an Option that shows line numbers in the text preview of
+// a Model.
+func LineNumbers() Option[*Model] {
+	return func(m *Model) *Model { return m.WithLineNumbers(true) }
+}
+
+// Images returns an Option that sets how a Model draws images in the preview
+// pane. By default, the protocol is detected from the terminal environment.
+func Images(protocol ImageProtocol) Option[*Model] {
+	return func(m *Model) *Model { return m.WithImages(protocol) }
+}
+
+// Output returns an Option that sets the writer a Model is rendered to, if
+// not os.Stdout. Images are drawn to it, so the program must render to the
+// writer returned by the Output method of the Model instead.
+func Output(w io.Writer) Option[*Model] {
+	return func(m *Model) *Model { return m.WithOutput(w) }
+}
+
+// Colors returns an Option that sets the colors of files listed by a Model, in
+// the format of either LS_COLORS or a dircolors database. By default, files
+// are colored by LS_COLORS, or by default colors if not set, unless NO_COLOR
+// is set. An empty spec disables colors.
+func Colors(spec string) Option[*Model] {
+	return func(m *Model) *Model { return m.WithColors(spec) }
+}
+
+// FollowLinks returns an Option that makes a Model enter the real directory
+// a symbolic link points to, instead of browsing it at the path of the link.
+func FollowLinks() Option[*Model] {
+	return func(m *Model) *Model { return m.WithFollowLinks(true) }
+}
+
+// FS returns an Option that makes a Model browse fsys instead of the file
+// system of the operating system. Paths are then names in fsys, starting at
+// "." by default. Files can only be deleted or renamed if fsys implements
+// RemoveFS or RenameFS, respectively, and are never moved to the trash.
+func FS(fsys fs.FS) Option[*Model] {
+	return func(m *Model) *Model { return m.WithFS(fsys) }
+}
+
 // Keys returns an Option that sets the key bindings for a Model.
 func Keys(keys *keyMap) Option[*Model] {
 	return func(m *Model) *Model { return m.WithKeys(keys) }
@@ -134,7 +288,9 @@ func Kill(status int) { os.Exit(status) }
 //
 // Init is a required method of the Bubble Tea framework's Model interface.
 func (m *Model) Init() tea.Cmd {
-	if m.path == "" {
+	if m.path == "" && m.fsys != nil {
+		m.path = "."
+	} else if m.path == "" {
 		var err error
 		m.path, err = os.Getwd()
 		if err != nil {
@@ -142,6 +298,11 @@ func (m *Model) Init() tea.Cmd {
 				m.st.Danger.Render("error: failed to get working directory"))
 		}
 	}
+	for i := range m.ignore {
+		if m.ignore[i].base == "" {
+			m.ignore[i].base = m.path // Patterns given before the path.
+		}
+	}
 	m.list()
 	return nil
 }
@@ -151,12 +312,28 @@ func (m *Model) Init() tea.Cmd {
 //
 // Update is a required method of the Bubble Tea framework's Model interface.
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+	// The cursor is placed on the file named prevName when laid out.
+	if m.findPrevName {
+		m.layout(m.listWidth(), m.listHeight())
+	}
+	if preview := m.schedulePreview(); preview != nil {
+		cmd = tea.Batch(cmd, preview)
+	}
+	if load := m.loadPreview(); load != nil {
+		cmd = tea.Batch(cmd, load)
+	}
+	if graphic := m.drawGraphic(); graphic != nil {
+		cmd = tea.Batch(cmd, graphic)
+	}
+	return model, cmd
+}
+
+func (m *Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		// Reset position history as c&r changes.
-		m.positions = make(map[string]position)
 		// Keep cursor at same place.
 		fileName, ok := m.fileName()
 		if ok {
@@ -169,52 +346,100 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
+		if len(m.jobs) > 0 && m.jobs[0].conflict != "" {
+			choice, ok := map[string]conflictChoice{
+				"o": conflictOverwrite,
+				"s": conflictSkip,
+				"r": conflictRename,
+				"a": conflictRenameAll,
+			}[msg.String()]
+			if ok {
+				m.jobs[0].resolve(choice)
+				return m, nil
+			}
+		}
+
+		if m.trashMode {
+			m.opErr = nil
+			switch {
+			case key.Matches(msg, m.keys.ForceQuit):
+				m.status = 2
+				m.dontDoPendingDeletions()
+				return m, m.quit()
+			case key.Matches(msg, m.keys.Trash, m.keys.Quit, m.keys.QuitQ, m.keys.Back):
+				m.trashMode = false
+			case key.Matches(msg, m.keys.Up, m.keys.VimUp):
+				m.trashCursor = max(m.trashCursor-1, 0)
+			case key.Matches(msg, m.keys.Down, m.keys.VimDown):
+				m.trashCursor = min(m.trashCursor+1, max(len(m.trashEntries)-1, 0))
+			case key.Matches(msg, m.keys.Restore):
+				m.restoreTrash()
+			}
+			return m, nil
+		}
+
+		if m.inputMode != inputNone {
+			return m.updateInput(msg)
+		}
+
+		if m.previewFocus {
+			return m.updatePreview(msg)
+		}
+
 		if m.searchMode {
 			if key.Matches(msg, m.keys.Search) {
 				m.searchMode = false
 				return m, nil
 			} else if key.Matches(msg, m.keys.Back) {
 				if len(m.search) > 0 {
-					m.search = m.search[:len(m.search)-1]
+					search := []rune(m.search)
+					if len(search) == 1 {
+						m.clearSearch()
+						m.searchMode = true
+						return m, nil
+					}
+					m.setSearch(string(search[:len(search)-1]))
+					m.c = 0
+					m.r = 0
+					m.offset = 0
+					m.saveCursorPosition()
 					return m, nil
 				}
 			} else if msg.Type == tea.KeyRunes {
-				m.search += string(msg.Runes)
-				names := make([]string, len(m.files))
-				for i, fi := range m.files {
-					names[i] = fi.Name()
-				}
-				matches := fuzzy.Find(m.search, names)
-				if len(matches) > 0 {
-					m.matchedIndexes = matches[0].MatchedIndexes
-					index := matches[0].Index
-					m.c = index / m.rows
-					m.r = index % m.rows
-				}
-				m.updateOffset()
+				// Best match is always listed first.
+				m.setSearch(m.search + string(msg.Runes))
+				m.c = 0
+				m.r = 0
+				m.offset = 0
 				m.saveCursorPosition()
-				// Save search id to clear only current search after delay.
-				// User may have already started typing next search.
-				searchId := m.searchId
-				return m, tea.Tick(2*time.Second, func(time.Time) tea.Msg {
-					return clearSearchMsg(searchId)
-				})
+				return m, m.searchTick()
 			}
 		}
 
+		if err := m.checkWritable(msg); err != nil {
+			m.opErr = err
+			return m, nil
+		}
+
 		switch {
 		case key.Matches(msg, m.keys.ForceQuit):
 			// _, _ = fmt.Fprintln(os.Stderr) // Keep last item visible after prompt.
 			m.status = 2
 			m.dontDoPendingDeletions()
-			return m, tea.Quit
+			return m, m.quit()
 
 		case key.Matches(msg, m.keys.Quit, m.keys.QuitQ):
 			// _, _ = fmt.Fprintln(os.Stderr) // Keep last item visible after prompt.
-			fmt.Println(m.path) // Write to cd.
+			dir, ok := m.osPath(m.path)
+			if m.archive != nil {
+				dir, ok = filepath.Dir(m.archive.path), true // Cannot cd into archive.
+			}
+			if ok {
+				fmt.Println(dir) // Write to cd.
+			}
 			m.status = 0
 			m.performPendingDeletions()
-			return m, tea.Quit
+			return m, m.quit()
 
 		case key.Matches(msg, m.keys.Open):
 			m.searchMode = false
@@ -222,19 +447,43 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if !ok {
 				return m, nil
 			}
-			if fi := fileInfo(filePath); fi.IsDir() {
+			info, err := m.stat(filePath)
+			if err != nil {
+				m.opErr = err
+				return m, nil
+			}
+			enter := info.IsDir()
+			if enter && m.followLinks {
+				// Enter the real directory of symlinks.
+				if target, err := m.linkTarget(filePath); err == nil {
+					filePath = target
+				}
+			}
+			if !enter && m.archive == nil && m.fsys == nil && archiveExt(filePath) != "" {
+				// Browse archive as a directory.
+				if err := m.enterArchive(filePath); err != nil {
+					m.opErr = err
+					return m, nil
+				}
+				enter = true
+			}
+			if enter {
 				// Enter subdirectory.
+				m.commitVisual()
 				m.path = filePath
-				if p, ok := m.positions[m.path]; ok {
-					m.c = p.c
-					m.r = p.r
-					m.offset = p.offset
-				} else {
+				m.leaveArchive()
+				if !m.restoreCursorPosition() {
 					m.c = 0
 					m.r = 0
 					m.offset = 0
 				}
 				m.list()
+			} else if m.archive != nil {
+				m.opErr = fmt.Errorf("cannot open %v in archive, e(X)tract it first", info.Name())
+				return m, nil
+			} else if _, ok := m.osPath(filePath); !ok {
+				m.opErr = fmt.Errorf("cannot open %v: not an OS file", info.Name())
+				return m, nil
 			} else {
 				// Open file. This will block until complete.
 				return m, m.openCommand()
@@ -242,18 +491,32 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case key.Matches(msg, m.keys.Back):
 			m.searchMode = false
-			m.prevName = filepath.Base(m.path)
-			m.path = filepath.Join(m.path, "..")
-			if p, ok := m.positions[m.path]; ok {
-				m.c = p.c
-				m.r = p.r
-				m.offset = p.offset
-			} else {
+			if m.fsys != nil && m.path == "." {
+				return m, nil // Already at root.
+			}
+			m.commitVisual()
+			m.prevName = m.base(m.path)
+			m.path = m.join(m.path, "..")
+			m.leaveArchive()
+			if !m.restoreCursorPosition() {
 				m.findPrevName = true
 			}
 			m.list()
 			return m, nil
 
+		case key.Matches(msg, m.keys.JumpLink):
+			if err := m.jumpToTarget(); err != nil {
+				m.opErr = err
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Extract):
+			cmd, err := m.extract()
+			if err != nil {
+				m.opErr = err
+			}
+			return m, cmd
+
 		case key.Matches(msg, m.keys.Up):
 			m.moveUp()
 
@@ -305,14 +568,131 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case key.Matches(msg, m.keys.Search):
+			// Continue editing the filter of the current path, if any.
 			m.searchMode = true
 			m.searchId++
-			m.search = ""
+
+		case key.Matches(msg, m.keys.ClearSearch):
+			if m.search == "" && !m.searchMode {
+				break
+			}
+			m.clearSearch()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Mark):
+			if m.toggleMark() {
+				m.moveDown()
+			}
+
+		case key.Matches(msg, m.keys.Visual):
+			m.toggleVisual()
+
+		case key.Matches(msg, m.keys.MarkAll):
+			m.markAll()
+
+		case key.Matches(msg, m.keys.InvertMarks):
+			m.invertMarks()
+
+		case key.Matches(msg, m.keys.ClearMarks):
+			m.clearMarks()
+
+		case key.Matches(msg, m.keys.Copy, m.keys.Cut):
+			m.register = m.Values()
+			m.registerCut = key.Matches(msg, m.keys.Cut)
+			m.clearMarks()
+			if len(m.register) > 0 {
+				op := jobCopy
+				if m.registerCut {
+					op = jobMove
+				}
+				m.notice = fmt.Sprintf("%d file(s) yanked to %v, (P)aste to %v here",
+					len(m.register), op, op)
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.Paste):
+			if len(m.register) == 0 {
+				break
+			}
+			op, srcs := jobCopy, m.register
+			if m.registerCut {
+				// Files are cut until moved, which they are only once.
+				if m.moving(srcs) {
+					m.notice = fmt.Sprintf("%d file(s) being moved already", len(srcs))
+					return m, nil
+				}
+				op = jobMove
+			}
+			m.jobId++
+			return m, m.queueJob(newJob(m.jobId, op, srcs, m.path))
+
+		case key.Matches(msg, m.keys.Rename):
+			if fileName, ok := m.fileName(); ok {
+				m.startInput(inputRename, "", fileName)
+			}
+			return m, nil
+
+		case key.Matches(msg, m.keys.NewFile):
+			m.startInput(inputNewFile, "new file: ", "")
+			return m, nil
+
+		case key.Matches(msg, m.keys.NewDir):
+			m.startInput(inputNewDir, "new directory: ", "")
+			return m, nil
+
+		case key.Matches(msg, m.keys.NewSymlink, m.keys.NewHardLink):
+			fileName, ok := m.fileName()
+			if !ok {
+				break
+			}
+			kind, prompt := inputSymlink, "symlink to %v: "
+			if key.Matches(msg, m.keys.NewHardLink) {
+				kind, prompt = inputHardLink, "hard link to %v: "
+			}
+			m.startInput(kind, fmt.Sprintf(prompt, fileName), "")
+			return m, nil
+
+		case key.Matches(msg, m.keys.BulkRename):
+			return m, m.bulkRename()
+
+		case key.Matches(msg, m.keys.Long):
+			m.longMode = !m.longMode
+			// Keep cursor at same place.
+			fileName, ok := m.fileName()
+			if ok {
+				m.prevName = fileName
+				m.findPrevName = true
+			}
+			m.c = 0
+			m.r = 0
+			m.offset = 0
+			return m, nil
+
+		case key.Matches(msg, m.keys.Trash):
+			m.openTrash()
+			return m, nil
+
+		case key.Matches(msg, m.keys.Raw):
+			m.markdownRaw = !m.markdownRaw
+			return m, nil
+
+		case key.Matches(msg, m.keys.CancelJob):
+			if len(m.jobs) > 0 {
+				m.jobs[0].cancel()
+			}
+
+		case key.Matches(msg, m.keys.NextMatch):
+			if m.search != "" {
+				m.moveMatch(1)
+			}
+
+		case key.Matches(msg, m.keys.PrevMatch):
+			if m.search != "" {
+				m.moveMatch(-1)
+			}
 
 		case key.Matches(msg, m.keys.Preview):
 			m.previewMode = !m.previewMode
-			// Reset position history as c&r changes.
-			m.positions = make(map[string]position)
 			// Keep cursor at same place.
 			fileName, ok := m.fileName()
 			if !ok {
@@ -324,9 +704,15 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.previewMode {
 				return m, tea.EnterAltScreen
 			}
-			m.previewContent = ""
+			m.cancelPreview()
+			m.previewBuf = nil
+			m.previewFocus = false
 			return m, tea.ExitAltScreen
 
+		case key.Matches(msg, m.keys.Focus):
+			m.previewFocus = m.previewMode
+			return m, nil
+
 		case key.Matches(msg, m.keys.Delete):
 			filePathToDelete, ok := m.filePath()
 			if ok {
@@ -337,7 +723,6 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						at:   time.Now().Add(6 * time.Second),
 					})
 					m.list()
-					m.previewContent = ""
 					return m, tea.Tick(time.Second, func(time.Time) tea.Msg {
 						return toBeDeletedMsg(0)
 					})
@@ -350,33 +735,132 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if len(m.toBeDeleted) > 0 {
 				m.toBeDeleted = m.toBeDeleted[:len(m.toBeDeleted)-1]
 				m.list()
-				m.previewContent = ""
 				return m, nil
 			}
 		case key.Matches(msg, m.keys.Yank):
 			// copy path to clipboard
-			clipboard.WriteAll(m.path)
+			if dir, ok := m.osPath(m.path); ok {
+				clipboard.WriteAll(dir)
+			}
 			m.yankSuccess = true
 			return m, nil
+
+		case key.Matches(msg, m.keys.Sort, m.keys.SortReverse):
+			if key.Matches(msg, m.keys.Sort) {
+				m.sortMode = m.sortMode.next()
+			} else {
+				m.sortReverse = !m.sortReverse
+			}
+			m.resort()
+			m.sortChanged = true
+			return m, nil
+
+		case key.Matches(msg, m.keys.Hidden):
+			m.showHidden = !m.showHidden
+			// Keep cursor at same place, if the file is still listed.
+			fileName, ok := m.fileName()
+			if ok {
+				m.prevName = fileName
+				m.findPrevName = true
+			}
+			m.c = 0
+			m.r = 0
+			m.offset = 0
+			m.list()
+			return m, nil
 		} // End of switch statement for key presses.
 
 		m.deleteCurrentFile = false
 		m.yankSuccess = false
+		m.sortChanged = false
+		m.notice = ""
+		m.opErr = nil
 		m.updateOffset()
 		m.saveCursorPosition()
 
+	case previewMsg:
+		m.receivePreview(msg)
+
+	case previewLinesMsg:
+		return m, m.receivePreviewLines(msg)
+
 	case clearSearchMsg:
 		if m.searchId == int(msg) {
 			m.searchMode = false
 		}
 
+	case bulkRenameMsg:
+		changes, err := m.applyBulkRename(msg)
+		m.opErr = err
+		if len(changes) > 0 {
+			m.notice = m.renameSummary(changes)
+		}
+		// Keep cursor at same place, following the file if renamed.
+		filePath, ok := m.filePath()
+		for _, change := range changes {
+			if change[0] == filePath && filepath.Dir(change[1]) == m.path {
+				filePath = change[1]
+			}
+		}
+		m.list()
+		if ok {
+			m.prevName = filepath.Base(filePath)
+			m.findPrevName = true
+		}
+
+	case jobProgressMsg:
+		if len(m.jobs) > 0 && m.jobs[0].id == msg.id {
+			m.jobs[0].progress = msg
+			return m, m.jobs[0].wait()
+		}
+
+	case jobConflictMsg:
+		if len(m.jobs) > 0 && m.jobs[0].id == msg.id {
+			m.jobs[0].conflict = msg.path
+			return m, m.jobs[0].wait()
+		}
+
+	case jobDoneMsg:
+		if len(m.jobs) == 0 || m.jobs[0].id != msg.id {
+			break
+		}
+		j := m.jobs[0]
+		j.cancel()
+		m.jobs = m.jobs[1:]
+		if j.op == jobMove {
+			m.uncut(j.srcs)
+		}
+		if m.quitting {
+			return m, m.exit()
+		}
+		if errors.Is(msg.err, context.Canceled) {
+			m.opErr = fmt.Errorf("%v cancelled", j.op)
+		} else if msg.err != nil {
+			m.opErr = fmt.Errorf("%v: %w", j.op, msg.err)
+		} else if j.op == jobExtract {
+			m.notice = fmt.Sprintf("extracted to %v", msg.last)
+		}
+		// Keep cursor at same place, or on the last file pasted here.
+		fileName, ok := m.fileName()
+		if msg.last != "" && filepath.Dir(msg.last) == m.path {
+			fileName, ok = filepath.Base(msg.last), true
+		}
+		m.list()
+		if ok {
+			m.prevName = fileName
+			m.findPrevName = true
+		}
+		if len(m.jobs) > 0 {
+			return m, m.jobs[0].start()
+		}
+
 	case toBeDeletedMsg:
 		toBeDeleted := make([]toDelete, 0)
 		for _, td := range m.toBeDeleted {
 			if td.at.After(time.Now()) {
 				toBeDeleted = append(toBeDeleted, td)
-			} else {
-				_ = os.RemoveAll(td.path)
+			} else if err := m.remove(td.path); err != nil {
+				m.opErr = err
 			}
 		}
 		m.toBeDeleted = toBeDeleted
@@ -395,33 +879,12 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 //
 // View is a required method of the Bubble Tea framework's Model interface.
 func (m *Model) View() string {
-	width := m.width
-	if m.previewMode {
-		width = m.width / 2
-	}
+	width := m.listWidth()
 	height := m.listHeight()
-
-	var names [][]string
-	names, m.rows, m.columns = wrap(m.files, width, height, func(name string, i, j int) {
-		if m.findPrevName && m.prevName == name {
-			m.c = i
-			m.r = j
-		}
-	})
-
-	// If we need to select previous directory on "up".
-	if m.findPrevName {
-		m.findPrevName = false
-		m.updateOffset()
-		m.saveCursorPosition()
-	}
-
-	// After we have updated offset and saved cursor position, we can
-	// preview currently selected file.
-	m.preview()
+	names := m.layout(width, height)
 
 	// Get output rows width before coloring.
-	outputWidth := len(path.Base(m.path)) // Use current dir name as default.
+	outputWidth := runewidth.StringWidth(m.base(m.path)) // Use current dir name as default.
 	if m.previewMode {
 		row := make([]string, m.columns)
 		for i := 0; i < m.columns; i++ {
@@ -431,24 +894,35 @@ func (m *Model) View() string {
 				outputWidth = width
 			}
 		}
-		outputWidth = max(outputWidth, len(Join(row, separator)))
+		outputWidth = max(outputWidth, runewidth.StringWidth(Join(row, separator)))
 	} else {
 		outputWidth = width
 	}
 
 	// Let's add colors to file names.
+	marks := m.markedSet()
 	output := make([]string, m.rows)
 	for j := 0; j < m.rows; j++ {
 		row := make([]string, m.columns)
 		for i := 0; i < m.columns; i++ {
+			n := i*m.rows + j
+			style, color := lipgloss.NewStyle(), lipgloss.NewStyle()
+			if n < len(m.files) {
+				if marks[m.join(m.path, m.files[n].Name())] {
+					style = m.st.Mark
+				}
+				color = m.fileColor(m.files[n])
+			}
 			if i == m.c && j == m.r {
-				if m.deleteCurrentFile {
+				if m.inputMode == inputRename {
+					row[i] = m.inlineInput(names[i][j], n)
+				} else if m.deleteCurrentFile {
 					row[i] = m.st.Danger.Render(names[i][j])
 				} else {
-					row[i] = m.st.Cursor.Render(names[i][j])
+					row[i] = m.highlight(names[i][j], n, m.st.Cursor.Copy().Inherit(style), color)
 				}
 			} else {
-				row[i] = names[i][j]
+				row[i] = m.highlight(names[i][j], n, style, color)
 			}
 		}
 		output[j] = Join(row, separator)
@@ -460,13 +934,19 @@ func (m *Model) View() string {
 
 	// Preview pane.
 	fileName, _ := m.fileName()
-	previewPane := m.st.Bar.Render(fileName) + "\n"
-	previewPane += m.previewContent
+	previewBar := m.st.Bar.Render(fileName)
+	if m.previewFocus {
+		previewBar = m.st.Search.Render(fileName) + m.st.Bar.Render(m.previewPosition())
+	}
+	previewPane := previewBar + "\n" + m.previewView()
 
 	// Location bar (grey).
-	location := m.path
+	location, ok := m.osPath(m.path)
+	if !ok {
+		location = m.path
+	}
 	if userHomeDir, err := os.UserHomeDir(); err == nil {
-		location = Replace(m.path, userHomeDir, "~", 1)
+		location = Replace(location, userHomeDir, "~", 1)
 	}
 	if runtime.GOOS == "windows" {
 		location = ReplaceAll(Replace(location, "\\/", fileSeparator, 1), "/", fileSeparator)
@@ -474,39 +954,78 @@ func (m *Model) View() string {
 
 	// Filter bar (green).
 	filter := ""
-	if m.searchMode {
+	if m.searchMode || m.search != "" {
 		location = TrimSuffix(location, fileSeparator)
 		filter = fileSeparator + m.search
 	}
-	barLen := len(location) + len(filter)
-	if barLen > outputWidth {
-		location = location[min(barLen-outputWidth, len(location)):]
-	}
+	location = truncateLeft(location, max(outputWidth-runewidth.StringWidth(filter), 0))
 	barStr := m.st.Bar.Render(location) + m.st.Search.Render(filter)
+	if len(marks) > 0 {
+		barStr += m.st.Mark.Render(fmt.Sprintf(" %d marked", len(marks)))
+	}
 
 	main := barStr + "\n" + Join(output, "\n")
 
 	if m.err != nil {
 		main = barStr + "\n" + m.st.Warning.Render(m.err.Error())
+	} else if len(m.files) == 0 && m.search != "" {
+		main = barStr + "\n" + m.st.Warning.Render("No matches")
 	} else if len(m.files) == 0 {
 		main = barStr + "\n" + m.st.Warning.Render("No files")
 	}
 
+	// Trash browser replaces listing.
+	if m.trashMode {
+		barStr = m.st.Bar.Render("Trash") + m.st.Search.Render(" (r)estore")
+		main = barStr + "\n" + m.trashView(height)
+	}
+
 	// Delete bar.
 	if len(m.toBeDeleted) > 0 {
 		toDelete := m.toBeDeleted[len(m.toBeDeleted)-1]
 		timeLeft := int(time.Until(toDelete.at).Seconds())
-		deleteBar := fmt.Sprintf("%v deleted. (u)ndo %v", path.Base(toDelete.path), timeLeft)
+		deleteBar := fmt.Sprintf("%v deleted. (u)ndo %v", m.base(toDelete.path), timeLeft)
 		main += "\n" + m.st.Danger.Render(deleteBar)
 	}
 
+	// Input prompt, unless shown inline.
+	if m.inputMode != inputNone && m.inputMode != inputRename {
+		main += "\n" + m.input.View()
+	}
+
+	// Job progress.
+	if len(m.jobs) > 0 {
+		main += "\n" + m.jobView(outputWidth)
+	}
+
+	// Operation failure.
+	if m.opErr != nil {
+		main += "\n" + m.st.Danger.Render(m.opErr.Error())
+	}
+
+	// Notice.
+	if m.notice != "" {
+		main += "\n" + m.st.Bar.Render(m.notice)
+	}
+
 	// Yank success.
 	if m.yankSuccess {
 		yankBar := fmt.Sprintf("yanked path to clipboard: %v", m.path)
 		main += "\n" + m.st.Bar.Render(yankBar)
 	}
 
+	// Sort order changed.
+	if m.sortChanged {
+		order := "ascending"
+		if m.sortReverse {
+			order = "descending"
+		}
+		sortBar := fmt.Sprintf("sorted by %v, %v", m.sortMode, order)
+		main += "\n" + m.st.Bar.Render(sortBar)
+	}
+
 	if m.previewMode {
+		m.previewColumn = lipgloss.Width(main) + m.st.Preview.GetPaddingLeft()
 		return lipgloss.JoinHorizontal(
 			lipgloss.Top,
 			main,
@@ -530,6 +1049,18 @@ func (m *Model) Value() string {
 	return path
 }
 
+// Values returns the paths of all marked files, including those in other
+// directories, or the path of the currently selected file if none are marked.
+func (m *Model) Values() []string {
+	if paths := m.marked(); len(paths) > 0 {
+		return paths
+	}
+	if path, ok := m.filePath(); ok {
+		return []string{path}
+	}
+	return nil
+}
+
 // With returns the receiver with the given options applied.
 func (m *Model) With(options ...Option[*Model]) *Model {
 	for _, option := range options {
@@ -556,10 +1087,26 @@ func (m *Model) Size() (width, height int) {
 	return m.width, m.height
 }
 
-// WithIcons returns the receiver with file type icons enabled.
+// WithIcons returns the receiver with file type icons enabled. Unless an
+// IconResolver is set, icons are read from the default and user icon files, and
+// any entries that cannot be parsed are logged.
 func (m *Model) WithIcons() *Model {
-	showIcons = true
-	parseIcons()
+	m.showIcons = true
+	if m.icons == nil {
+		icons, warnings := loadIcons()
+		m.icons = icons
+		for _, err := range warnings {
+			log.Printf("icons: %v", err)
+		}
+	}
+	return m
+}
+
+// WithIconResolver returns the receiver with file type icons returned by
+// resolver enabled.
+func (m *Model) WithIconResolver(resolver IconResolver) *Model {
+	m.showIcons = true
+	m.icons = resolver
 	return m
 }
 
@@ -575,6 +1122,117 @@ func (m *Model) WithStyle(styles *Styles) *Model {
 	return m
 }
 
+// WithSort returns the receiver with the given sort mode and order set.
+func (m *Model) WithSort(mode SortMode, reverse bool) *Model {
+	m.sortMode = mode
+	m.sortReverse = reverse
+	return m
+}
+
+// WithHidden returns the receiver with hidden and ignored files shown or not.
+func (m *Model) WithHidden(show bool) *Model {
+	m.showHidden = show
+	return m
+}
+
+// WithIgnore returns the receiver with the given ignore patterns added.
+// Patterns containing a slash are relative to the current path, or to the
+// starting path if not set yet.
+func (m *Model) WithIgnore(patterns ...string) *Model {
+	for _, pattern := range patterns {
+		if rule, ok := parseIgnoreRule(m.path, pattern); ok {
+			m.ignore = append(m.ignore, rule)
+		}
+	}
+	return m
+}
+
+// WithIgnoreFiles returns the receiver with .gitignore and .ignore files
+// honored or not.
+func (m *Model) WithIgnoreFiles(honor bool) *Model {
+	m.ignoreFiles = honor
+	return m
+}
+
+// WithSearchTimeout returns the receiver with the given type-to-filter
+// timeout set. A zero timeout disables it.
+func (m *Model) WithSearchTimeout(timeout time.Duration) *Model {
+	m.searchTimeout = timeout
+	return m
+}
+
+// WithTrash returns the receiver with deleted files moved to the trash or
+// removed permanently.
+func (m *Model) WithTrash(trash bool) *Model {
+	m.trash = trash
+	return m
+}
+
+// WithLong returns the receiver with long listing mode enabled or not, showing
+// the given metadata columns, or DefaultColumns if none given.
+func (m *Model) WithLong(long bool, columns ...Column) *Model {
+	m.longMode = long
+	m.longColumns = columns
+	return m
+}
+
+// WithSyntaxStyle returns the receiver with the given chroma style name set.
+func (m *Model) WithSyntaxStyle(name string) *Model {
+	m.syntaxStyle = name
+	return m
+}
+
+// WithLineNumbers returns the receiver with line numbers in text preview
+// shown or not.
+func (m *Model) WithLineNumbers(show bool) *Model {
+	m.lineNumbers = show
+	return m
+}
+
+// WithImages returns the receiver with the given image protocol set.
+func (m *Model) WithImages(protocol ImageProtocol) *Model {
+	m.imageProtocol = protocol
+	return m
+}
+
+// WithOutput returns the receiver with the given output writer set.
+func (m *Model) WithOutput(w io.Writer) *Model {
+	m.output = &syncWriter{w: w}
+	return m
+}
+
+// Output returns the writer that the program must render the receiver to,
+// e.g., with tea.WithOutput, so that images drawn by the receiver do not
+// interleave with the frames rendered.
+func (m *Model) Output() io.Writer {
+	return m.output
+}
+
+// WithColors returns the receiver with the colors of files in spec set, or
+// with colors disabled if spec is empty.
+func (m *Model) WithColors(spec string) *Model {
+	m.colors = nil
+	if spec != "" {
+		m.colors = parseColors(spec)
+	}
+	m.colorFiles()
+	return m
+}
+
+// WithFollowLinks returns the receiver entering the real directories of
+// symbolic links if follow is true.
+func (m *Model) WithFollowLinks(follow bool) *Model {
+	m.followLinks = follow
+	return m
+}
+
+// WithFS returns the receiver browsing fsys, or the file system of the
+// operating system if nil.
+func (m *Model) WithFS(fsys fs.FS) *Model {
+	m.fsys = fsys
+	return m
+}
+
 // WithKeys returns the receiver with the given key bindings set.
 func (m *Model) WithKeys(keys *keyMap) *Model {
 	m.keys = keys
@@ -692,10 +1350,12 @@ func (m *Model) moveEnd() {
 
 func (m *Model) list() {
 	var err error
+	m.commitVisual()
 	m.files = nil
+	m.listing = nil
+	m.search = m.filters[m.path]
 
-	// ReadDir already returns files and dirs sorted by filename.
-	files, err := os.ReadDir(m.path)
+	files, err := m.readDir(m.path)
 	if err != nil {
 		m.err = err
 		return
@@ -703,14 +1363,63 @@ func (m *Model) list() {
 	m.err = nil
 
 files:
-	for _, file := range files {
+	for _, file := range m.visible(m.path, files) {
 		for _, toDelete := range m.toBeDeleted {
-			if path.Join(m.path, file.Name()) == toDelete.path {
+			if m.join(m.path, file.Name()) == toDelete.path {
 				continue files
 			}
 		}
-		m.files = append(m.files, file)
+		m.listing = append(m.listing, file)
+	}
+	sortFiles(m.listing, m.sortMode, m.sortReverse)
+	m.pruneMarks()
+	m.linkFiles()
+	m.colorFiles()
+	m.applyFilter()
+}
+
+// resort sorts the current listing again, keeping the cursor on the same file.
+func (m *Model) resort() {
+	m.commitVisual()
+	fileName, ok := m.fileName()
+	sortFiles(m.listing, m.sortMode, m.sortReverse)
+	m.applyFilter()
+	if ok {
+		m.prevName = fileName
+		m.findPrevName = true
+	}
+}
+
+// layout wraps the names of files to fit in width and height, and places the
+// cursor on the file named prevName, if requested.
+func (m *Model) layout(width, height int) [][]string {
+	var names [][]string
+	findPrevName := func(name string, i, j int) {
+		if m.findPrevName && m.prevName == name {
+			m.c = i
+			m.r = j
+		}
+	}
+	if m.longMode {
+		names, m.rows, m.columns = m.longWrap(m.files, width, findPrevName)
+	} else {
+		names, m.rows, m.columns = m.wrap(m.path, m.files, width, height, findPrevName)
+	}
+
+	// If we need to select previous directory on "up".
+	if m.findPrevName {
+		m.findPrevName = false
+		m.updateOffset()
+		m.saveCursorPosition()
+	}
+	return names
+}
+
+func (m *Model) listWidth() int {
+	if m.previewMode {
+		return m.width / 2
 	}
+	return m.width
 }
 
 func (m *Model) listHeight() int {
@@ -718,7 +1427,13 @@ func (m *Model) listHeight() int {
 	if len(m.toBeDeleted) > 0 {
 		h-- // Subtract 1 for delete bar.
 	}
-	return h
+	if len(m.jobs) > 0 {
+		h-- // Subtract 1 for progress bar.
+	}
+	if m.inputMode != inputNone && m.inputMode != inputRename {
+		h-- // Subtract 1 for input prompt.
+	}
+	return max(h, 1)
 }
 
 func (m *Model) updateOffset() {
@@ -738,11 +1453,31 @@ func (m *Model) updateOffset() {
 }
 
 func (m *Model) saveCursorPosition() {
+	name, _ := m.fileName()
 	m.positions[m.path] = position{
 		c:      m.c,
 		r:      m.r,
 		offset: m.offset,
+		name:   name,
+	}
+}
+
+// restoreCursorPosition restores the cursor position saved for the current
+// path, if any. The saved file name takes precedence over the saved c&r, so
+// that the same file stays selected even if the listing was re-sorted.
+func (m *Model) restoreCursorPosition() bool {
+	p, ok := m.positions[m.path]
+	if !ok {
+		return false
+	}
+	m.c = p.c
+	m.r = p.r
+	m.offset = p.offset
+	if p.name != "" {
+		m.prevName = p.name
+		m.findPrevName = true
 	}
+	return true
 }
 
 func (m *Model) fileName() (string, bool) {
@@ -758,7 +1493,7 @@ func (m *Model) filePath() (string, bool) {
 	if !ok {
 		return fileName, false
 	}
-	return path.Join(m.path, fileName), true
+	return m.join(m.path, fileName), true
 }
 
 func (m *Model) openCommand() tea.Cmd {
@@ -766,8 +1501,22 @@ func (m *Model) openCommand() tea.Cmd {
 	if !ok {
 		return nil
 	}
+	if filePath, ok = m.osPath(filePath); !ok {
+		return nil
+	}
+	return m.execCommand(filePath, func(err error) tea.Msg {
+		// Note: we could return a message here indicating that editing is
+		// finished and altering our application about any errors. For now,
+		// however, that's not necessary.
+		return nil
+	})
+}
 
-	cmdline := m.cmdline
+// execCommand returns a command that runs the "open file" command line with
+// the given file path, calling fn with any error once it exits.
+func (m *Model) execCommand(filePath string, fn tea.ExecCallback) tea.Cmd {
+	// Copy the command line so that replacing {} doesn't modify m.cmdline.
+	cmdline := append([]string(nil), m.cmdline...)
 	if len(cmdline) == 0 || cmdline[0] == "" {
 		cmdline = Fields(lookup([]string{"LK_COMMAND", "EDITOR"}, "less"))
 	}
@@ -783,93 +1532,7 @@ func (m *Model) openCommand() tea.Cmd {
 	}
 
 	execCmd := exec.Command(cmdline[0], cmdline[1:]...)
-	return tea.ExecProcess(execCmd, func(err error) tea.Msg {
-		// Note: we could return a message here indicating that editing is
-		// finished and altering our application about any errors. For now,
-		// however, that's not necessary.
-		return nil
-	})
-}
-
-func (m *Model) preview() {
-	if !m.previewMode {
-		return
-	}
-	filePath, ok := m.filePath()
-	if !ok {
-		return
-	}
-
-	fileInfo, err := os.Stat(filePath)
-	if err != nil {
-		return
-	}
-
-	width := m.width / 2
-	height := m.height - 1 // Subtract 1 for name bar.
-
-	if fileInfo.IsDir() {
-		files, err := os.ReadDir(filePath)
-		if err != nil {
-			m.previewContent = err.Error()
-		}
-
-		names, rows, columns := wrap(files, width, height, nil)
-
-		output := make([]string, rows)
-		for j := 0; j < rows; j++ {
-			row := make([]string, columns)
-			for i := 0; i < columns; i++ {
-				row[i] = names[i][j]
-			}
-			output[j] = Join(row, separator)
-		}
-		if len(output) >= height {
-			output = output[0:height]
-		}
-		m.previewContent = Join(output, "\n")
-		return
-	}
-
-	if isImageExt(filePath) {
-		img, err := drawImage(filePath, width, height)
-		if err != nil {
-			m.previewContent = m.st.Warning.Render("No image preview available")
-			return
-		}
-		m.previewContent = img
-		return
-	}
-
-	var content []byte
-	// If file is too big (> 100kb), read only first 100kb.
-	if fileInfo.Size() > 100*1024 {
-		file, err := os.Open(filePath)
-		if err != nil {
-			m.previewContent = err.Error()
-			return
-		}
-		defer file.Close()
-		content = make([]byte, 100*1024)
-		_, err = file.Read(content)
-		if err != nil {
-			m.previewContent = err.Error()
-			return
-		}
-	} else {
-		content, err = os.ReadFile(filePath)
-		if err != nil {
-			m.previewContent = err.Error()
-			return
-		}
-	}
-
-	switch {
-	case utf8.Valid(content):
-		m.previewContent = leaveOnlyAscii(content)
-	default:
-		m.previewContent = m.st.Warning.Render("No preview available")
-	}
+	return tea.ExecProcess(execCmd, fn)
 }
 
 func (m *Model) dontDoPendingDeletions() {
@@ -880,7 +1543,9 @@ func (m *Model) dontDoPendingDeletions() {
 
 func (m *Model) performPendingDeletions() {
 	for _, toDelete := range m.toBeDeleted {
-		_ = os.RemoveAll(toDelete.path)
+		if err := m.remove(toDelete.path); err != nil {
+			fmt.Fprintf(os.Stderr, "Was not deleted: %v\n", err)
+		}
 	}
 	m.toBeDeleted = nil
 }
@@ -0,0 +1,139 @@
+package walk
+
+import (
+	"fmt"
+	"log"
+	"os"
+	. "strings"
+	"testing"
+	"testing/fstest"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+)
+
+// press returns the message of pressing the key k, e.g., "a" or "end".
+func press(k string) tea.KeyMsg {
+	if k == "end" {
+		return tea.KeyMsg{Type: tea.KeyEnd}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+}
+
+// send updates m with msg, then with the messages of the preview commands
+// returned, until there are none.
+func send(m *Model, msg tea.Msg) {
+	_, cmd := m.Update(msg)
+	run(m, cmd)
+}
+
+// run runs cmd and updates m with the preview messages it returns.
+func run(m *Model, cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+	switch msg := cmd().(type) {
+	case tea.BatchMsg:
+		for _, cmd := range msg {
+			run(m, cmd)
+		}
+	case previewMsg, previewLinesMsg:
+		send(m, msg)
+	}
+}
+
+func TestSmallHeight(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for i := 0; i < 20; i++ {
+		fsys[fmt.Sprintf("file%d", i)] = &fstest.MapFile{}
+	}
+	for height := 1; height <= 6; height++ {
+		m := New(FS(fsys), Size(80, height))
+		m.Init()
+		m.jobs = append(m.jobs, newJob(1, jobCopy, nil, "."))
+		m.startInput(inputNewFile, "new file: ", "")
+		m.updateOffset()
+		// The path, input and job take a line each, leaving at least one
+		// for the list.
+		lines := Split(m.View(), "\n")
+		if len(lines) != max(height, 4) {
+			t.Fatalf("height %d: %d lines: %q", height, len(lines), lines)
+		}
+		if rows := lines[1 : len(lines)-2]; !HasPrefix(rows[0], "file0 ") {
+			t.Errorf("height %d: listed %q", height, rows)
+		}
+		if !HasPrefix(lines[len(lines)-2], "new file: ") || !HasPrefix(lines[len(lines)-1], "copy . ") {
+			t.Errorf("height %d: input and job %q", height, lines[len(lines)-2:])
+		}
+	}
+}
+
+func TestWrapTruncatesToColumn(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for i := 0; i < 29; i++ {
+		fsys[fmt.Sprintf("file%02d", i)] = &fstest.MapFile{}
+	}
+	long := "long-" + Repeat("名", 30) + ".txt"
+	fsys[long] = &fstest.MapFile{}
+	m := New(FS(fsys), Size(80, 24))
+	m.Init()
+	names, _, columns := m.wrap(m.path, m.files, 80, 24, nil)
+	if columns != 3 {
+		t.Fatalf("laid out in %d columns, want 3", columns)
+	}
+	column := (80 - 2*len(separator)) / 3
+	for i := range names {
+		for _, name := range names[i] {
+			if w := runewidth.StringWidth(name); w > column {
+				t.Errorf("%q is %d cells wide, want at most %d", name, w, column)
+			}
+		}
+	}
+	if cell := TrimSpace(names[2][len(names[2])-1]); !HasPrefix(cell, "long-") || !HasSuffix(cell, ".txt") || !Contains(cell, ellipsis) {
+		t.Errorf("long name truncated to %q", cell)
+	}
+
+	// Names are not truncated if they fit.
+	delete(fsys, long)
+	m = New(FS(fsys), Size(80, 24))
+	m.Init()
+	names, _, _ = m.wrap(m.path, m.files, 80, 24, nil)
+	for i := range names {
+		for _, name := range names[i] {
+			if Contains(name, ellipsis) {
+				t.Errorf("%q truncated", name)
+			}
+		}
+	}
+}
+
+func TestMarksPruned(t *testing.T) {
+	fsys := testFS()
+	m := New(FS(fsys), Size(80, 24))
+	m.Init()
+	for _, name := range []string{"top.txt", "dir"} {
+		moveTo(t, m, name)
+		m.toggleMark()
+	}
+	delete(fsys, "top.txt")
+	m.list()
+	if got := m.Values(); len(got) != 1 || got[0] != "dir" {
+		t.Errorf("values = %q, want only dir", got)
+	}
+}
+
+func TestIconWarningsLogged(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(iconsEnv, "di=D:bad")
+	var buf Builder
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	m := New(Path(".")).WithIcons()
+	if !Contains(buf.String(), "icons: "+iconsEnv+": expected key=icon but found: bad") {
+		t.Errorf("logged %q", buf.String())
+	}
+	if m.notice != "" {
+		t.Errorf("notice = %q", m.notice)
+	}
+}